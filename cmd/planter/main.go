@@ -0,0 +1,260 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"log"
+	"os"
+	"os/signal"
+	"strings"
+
+	"github.com/alecthomas/kingpin"
+	"github.com/maocatooo/planter"
+	planterdriver "github.com/maocatooo/planter/driver"
+	"github.com/maocatooo/planter/lint"
+	"github.com/maocatooo/planter/render"
+	"github.com/maocatooo/planter/schema"
+	"github.com/pkg/errors"
+)
+
+var (
+	renderCmd = kingpin.Command("render", "generate the diagram of a schema").Default()
+	connStr   = renderCmd.Arg(
+		"conn", "MySQL/PostgreSQL connection string in URL format, SQLite database file or DDL file").String()
+	fromSnapshot = renderCmd.Flag("from-snapshot", "load the schema from a json snapshot written with --format json").String()
+
+	diffCmd  = kingpin.Command("diff", "compare two schemas, reported as text or as a colored PlantUML diagram")
+	diffFrom = diffCmd.Arg("from", "current schema, connection string or file").Required().String()
+	diffTo   = diffCmd.Arg("to", "desired schema, connection string or file").Required().String()
+	toDriver = diffCmd.Flag("to-driver", "driver of <to>, Default --driver").String()
+
+	lintCmd     = kingpin.Command("lint", "check a schema for design problems, exits with status 1 when issues are found")
+	lintConn    = lintCmd.Arg("conn", "connection string or file").Required().String()
+	lintConfig  = lintCmd.Flag("config", `json rule config, e.g. {"rules": {"missing-column-comment": false}}`).String()
+	lintEnable  = lintCmd.Flag("enable", "enable a rule disabled by --config").Strings()
+	lintDisable = lintCmd.Flag("disable", "disable a rule, e.g. --disable naming").Strings()
+
+	driver         = kingpin.Flag("driver", "driver mysql/postgres/sqlite/ddl/snapshot, Default mysql").Default("mysql").Short('d').String()
+	postgresSchema = kingpin.Flag(
+		"schema", "PostgreSQL schema name").Default("public").Short('s').String()
+	outFile     = kingpin.Flag("output", "output file path").Short('o').String()
+	targetTbls  = kingpin.Flag("table", "keep only the tables matching this pattern, repeatable").Short('t').Strings()
+	xTargetTbls = kingpin.Flag("exclude", "drop the tables matching this pattern, repeatable").Short('x').Strings()
+	match       = kingpin.Flag("match", "how -t/-x patterns match table names: exact, glob (e.g. user_*) or regex (anchored)").Default("glob").Enum("exact", "glob", "regex")
+	inferFKs    = kingpin.Flag("infer-fks", "infer foreign keys from column names: auto (only when the schema has none), missing (tables without foreign keys) or off").Default("auto").Enum("auto", "missing", "off")
+	fkSuffixes  = kingpin.Flag("fk-suffix", "column suffix marking an inferred reference, repeatable, Default _id, Id and _fk").Strings()
+	tblPrefixes = kingpin.Flag("table-prefix", "table name prefix ignored by foreign key inference, repeatable, e.g. tbl_").Strings()
+	sampleRows  = kingpin.Flag("sample", "check inferred foreign keys against the data by looking up this many values of each column in the referenced table, 0 disables it (mysql/postgres/sqlite)").Default("0").Int()
+	sampleLimit = kingpin.Flag("sample-timeout", "limit of every --sample query, a foreign key whose query runs longer is kept unchecked").Default("5s").Duration()
+	minConf     = kingpin.Flag("min-confidence", "drop the inferred foreign keys with a lower share of --sample values found in the referenced table").Default("0.9").Float64()
+	focus       = kingpin.Flag("focus", "keep only this table and its neighbors, repeatable").Strings()
+	depth       = kingpin.Flag("depth", "foreign key hops walked from --focus").Default("1").Int()
+	direction   = kingpin.Flag("direction", "foreign keys walked from --focus, out follows references, in finds referencing tables").Default("both").Enum("both", "out", "in")
+	title       = kingpin.Flag("title", "Diagram title").Short('T').String()
+	svg         = kingpin.Flag("svg", "gen svg").Bool()
+	svgServer   = kingpin.Flag("svg-server", "PlantUML/Kroki server URL used by --svg, e.g. https://kroki.io/plantuml/svg").String()
+	plantumlBin = kingpin.Flag("plantuml", "plantuml executable or plantuml.jar used by --svg").String()
+	concurrency = kingpin.Flag("concurrency", "number of connections used to load the tables selected by -t/-x").Default("1").Int()
+	timeout     = kingpin.Flag("timeout", "abort loading the schema after this duration, e.g. 30s, 0 waits forever").Default("0").Duration()
+	hideColumns = kingpin.Flag("hide-columns", "comma separated column globs to leave out of the diagram, table.column for one table, e.g. 'created_at,audit_*.payload'").Strings()
+	keysOnly    = kingpin.Flag("keys-only", "draw only primary key and foreign key columns").Bool()
+	collapse    = kingpin.Flag("collapse-junctions", "draw many to many junction tables as a relation between the tables they join").Bool()
+	showIndexes = kingpin.Flag("show-indexes", "list the indexes of every table below its columns (plantuml/dot/svg)").Bool()
+	format      = kingpin.Flag("format", "output format plantuml/mermaid/dot/json, text for diff and lint").Default("plantuml").Short('f').Enum("plantuml", "mermaid", "dot", "json", "text")
+)
+
+func main() {
+	cmd := kingpin.Parse()
+
+	// Ctrl-C cancels the running catalog query
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	if *timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, *timeout)
+		defer cancel()
+	}
+
+	var (
+		buf bytes.Buffer
+		err error
+	)
+	switch cmd {
+	case diffCmd.FullCommand():
+		err = runDiff(ctx, &buf)
+	case lintCmd.FullCommand():
+		err = runLint(ctx, &buf)
+	default:
+		err = runRender(ctx, &buf)
+	}
+	stop()
+	// the lint report is still written before exiting with status 1
+	failed := errors.Is(err, errLintIssues)
+	if failed {
+		err = nil
+	}
+	if errors.Is(err, context.DeadlineExceeded) {
+		log.Fatalf("%s (--timeout %s)", err, *timeout)
+	}
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	out := os.Stdout
+	if *outFile != "" {
+		out, err = os.Create(*outFile)
+		if err != nil {
+			log.Fatalf("failed to create output file %s: %s", *outFile, err)
+		}
+	}
+	if _, err := buf.WriteTo(out); err != nil {
+		log.Fatal(err)
+	}
+	if err := out.Close(); err != nil && *outFile != "" {
+		log.Fatal(err)
+	}
+	if failed {
+		os.Exit(1)
+	}
+}
+
+func loadOptions() planter.LoadOptions {
+	return planter.LoadOptions{
+		Schema:      *postgresSchema,
+		Tables:      *targetTbls,
+		Exclude:     *xTargetTbls,
+		Match:       schema.MatchMode(*match),
+		Focus:       *focus,
+		Depth:       *depth,
+		Direction:   schema.Direction(*direction),
+		Concurrency: *concurrency,
+		Infer: schema.InferOptions{
+			Mode:     schema.InferMode(*inferFKs),
+			Suffixes: *fkSuffixes,
+			Prefixes: *tblPrefixes,
+		},
+		Sample: planterdriver.SampleOptions{
+			Rows:          *sampleRows,
+			Timeout:       *sampleLimit,
+			MinConfidence: *minConf,
+		},
+	}
+}
+
+// renderOptions map --svg/--svg-server/--plantuml to the output format and renderer
+func renderOptions() (planter.Format, planter.RenderOptions, error) {
+	f := planter.Format(*format)
+	opts := planter.RenderOptions{Title: *title, ShowIndexes: *showIndexes, KeysOnly: *keysOnly, CollapseJunctions: *collapse}
+	for _, v := range *hideColumns {
+		for _, p := range strings.Split(v, ",") {
+			if p = strings.TrimSpace(p); p != "" {
+				opts.HideColumns = append(opts.HideColumns, p)
+			}
+		}
+	}
+	if !*svg {
+		return f, opts, nil
+	}
+	if *svgServer != "" || *plantumlBin != "" {
+		r, err := render.NewSVGRenderer(*svgServer, *plantumlBin)
+		if err != nil {
+			return "", opts, err
+		}
+		opts.SVGRenderer = r
+	}
+	return planter.FormatSVG, opts, nil
+}
+
+func runRender(ctx context.Context, buf *bytes.Buffer) error {
+	driverName, conn := *driver, *connStr
+	if *fromSnapshot != "" {
+		driverName, conn = "snapshot", *fromSnapshot
+	}
+	if conn == "" {
+		return errors.New("required argument 'conn' not provided")
+	}
+	switch {
+	case *format == "text":
+		return errors.New("text format is only supported by diff")
+	case *svg && *format != "plantuml":
+		return errors.New("--svg is only supported for plantuml format")
+	}
+	f, opts, err := renderOptions()
+	if err != nil {
+		return err
+	}
+
+	s, err := planter.Load(ctx, driverName, conn, loadOptions())
+	if err != nil {
+		return err
+	}
+	return planter.Render(s, f, buf, opts)
+}
+
+func runDiff(ctx context.Context, buf *bytes.Buffer) error {
+	toDriverName := *driver
+	if *toDriver != "" {
+		toDriverName = *toDriver
+	}
+	if *svg && *format != "plantuml" {
+		return errors.New("--svg is only supported for plantuml format")
+	}
+	f, opts, err := renderOptions()
+	if err != nil {
+		return err
+	}
+
+	from, err := planter.Load(ctx, *driver, *diffFrom, loadOptions())
+	if err != nil {
+		return errors.Wrapf(err, "failed to load %s", *diffFrom)
+	}
+	to, err := planter.Load(ctx, toDriverName, *diffTo, loadOptions())
+	if err != nil {
+		return errors.Wrapf(err, "failed to load %s", *diffTo)
+	}
+	return planter.RenderDiff(to, planter.Diff(from, to), f, buf, opts)
+}
+
+var errLintIssues = errors.New("lint issues found")
+
+func runLint(ctx context.Context, buf *bytes.Buffer) error {
+	var f planter.Format
+	switch *format {
+	case "json":
+		f = planter.FormatJSON
+	case "plantuml", "text":
+		// plantuml is the global default, lint reports text unless asked for json
+		f = planter.FormatText
+	default:
+		return errors.Errorf("%s format is not supported by lint, use text or json", *format)
+	}
+
+	var cfg lint.Config
+	if *lintConfig != "" {
+		var err error
+		if cfg, err = lint.LoadConfig(*lintConfig); err != nil {
+			return err
+		}
+	}
+	for _, name := range *lintEnable {
+		cfg.Set(name, true)
+	}
+	for _, name := range *lintDisable {
+		cfg.Set(name, false)
+	}
+
+	s, err := planter.Load(ctx, *driver, *lintConn, loadOptions())
+	if err != nil {
+		return err
+	}
+	issues, err := planter.Lint(s, cfg)
+	if err != nil {
+		return err
+	}
+	if err := planter.RenderLint(issues, f, buf); err != nil {
+		return err
+	}
+	if len(issues) != 0 {
+		return errLintIssues
+	}
+	return nil
+}
@@ -0,0 +1,234 @@
+package driver
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/maocatooo/planter/schema"
+)
+
+func newFakePlanter(name string, db Queryer, opts Options) Planter {
+	switch name {
+	case "mysql":
+		m := NewMysql(opts).(*mysql)
+		m.db = db
+		return m
+	case "postgres":
+		m := NewPostgres(opts).(*postgres)
+		m.db = db
+		return m
+	}
+	panic("unknown fake driver " + name)
+}
+
+func newFakeDB(name string, n int, latency time.Duration) *fakeDB {
+	if name == "mysql" {
+		return fakeMySQL(n, latency)
+	}
+	return fakePostgres(n, latency)
+}
+
+func Test_LoadTableDef_bulk(t *testing.T) {
+	for name, bulkQueries := range map[string]int64{"mysql": 6, "postgres": 5} {
+		t.Run(name, func(t *testing.T) {
+			const n = 20
+			bulkDB := newFakeDB(name, n, 0)
+			bulk, err := newFakePlanter(name, bulkDB.open(), Options{}).LoadTableDef(context.Background())
+			if err != nil {
+				t.Fatal(err)
+			}
+			if q := atomic.LoadInt64(&bulkDB.queries); q != bulkQueries {
+				t.Errorf("bulk load ran %d queries, want %d", q, bulkQueries)
+			}
+
+			// a filter switches to per-table queries
+			all := func(string) bool { return true }
+			perTableDB := newFakeDB(name, n, 0)
+			perTable, err := newFakePlanter(name, perTableDB.open(), Options{Filter: all}).LoadTableDef(context.Background())
+			if err != nil {
+				t.Fatal(err)
+			}
+			if q := atomic.LoadInt64(&perTableDB.queries); q != bulkQueries-4+4*n {
+				t.Errorf("per-table load ran %d queries, want %d", q, bulkQueries-4+4*n)
+			}
+			if len(bulk) != n {
+				t.Fatalf("tables = %d, want %d", len(bulk), n)
+			}
+			if d := schema.DiffTables(perTable, bulk); !d.Empty() {
+				t.Errorf("bulk and per-table loads differ: %+v", d)
+			}
+			fk := bulk[1].ForeingKeys
+			if name == "mysql" {
+				// mysql lists the fks on the referenced table
+				fk = bulk[0].ForeingKeys
+			}
+			if len(fk) != 1 || fk[0].SourceColumn == nil || fk[0].TargetColumn == nil {
+				t.Errorf("fk not linked: %+v", fk)
+			}
+			uks := bulk[1].UniqueKeys
+			if len(uks) != 1 || len(uks[0].Columns) != 1 || uks[0].Columns[0] != bulk[1].Columns[1] ||
+				!bulk[1].Columns[1].IsUnique || bulk[1].Columns[0].IsUnique {
+				t.Errorf("unique key not linked: %+v", uks)
+			}
+			idxs := bulk[1].Indexes
+			if len(idxs) != 2 || idxs[0].Unique == idxs[1].Unique || idxs[0].Method != "btree" {
+				t.Errorf("indexes = %+v", idxs)
+			}
+		})
+	}
+}
+
+func Test_LoadTableDef_filter(t *testing.T) {
+	for _, name := range []string{"mysql", "postgres"} {
+		t.Run(name, func(t *testing.T) {
+			db := newFakeDB(name, 10, 0)
+			keep, err := schema.TableFilter([]string{"t000[3-5]"}, []string{"t0005"}, schema.MatchGlob)
+			if err != nil {
+				t.Fatal(err)
+			}
+			tbls, err := newFakePlanter(name, db.open(), Options{Filter: keep}).LoadTableDef(context.Background())
+			if err != nil {
+				t.Fatal(err)
+			}
+			var names []string
+			for _, tbl := range tbls {
+				names = append(names, tbl.Name)
+			}
+			if got := strings.Join(names, ","); got != "t0003,t0004" {
+				t.Errorf("tables = %s, want t0003,t0004", got)
+			}
+			// t0003 -> t0002 points outside the filter and is dropped
+			var fks int
+			for _, tbl := range tbls {
+				fks += len(tbl.ForeingKeys)
+			}
+			if fks != 1 {
+				t.Errorf("fks = %d, want 1", fks)
+			}
+		})
+	}
+}
+
+func Test_LoadTableDef_concurrency(t *testing.T) {
+	for _, name := range []string{"mysql", "postgres"} {
+		t.Run(name, func(t *testing.T) {
+			const n = 40
+			all := func(string) bool { return true }
+			seq, err := newFakePlanter(name, newFakeDB(name, n, 0).open(), Options{Filter: all}).LoadTableDef(context.Background())
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			db := newFakeDB(name, n, time.Millisecond)
+			conn := db.open()
+			conn.SetMaxOpenConns(4)
+			tbls, err := newFakePlanter(name, conn, Options{Filter: all, Concurrency: 4}).LoadTableDef(context.Background())
+			if err != nil {
+				t.Fatal(err)
+			}
+			if max := atomic.LoadInt64(&db.maxInflight); max < 2 || max > 4 {
+				t.Errorf("max queries in flight = %d, want 2..4", max)
+			}
+			for i := range seq {
+				if tbls[i].Name != seq[i].Name {
+					t.Fatalf("table %d = %s, want %s", i, tbls[i].Name, seq[i].Name)
+				}
+			}
+			if d := schema.DiffTables(seq, tbls); !d.Empty() {
+				t.Errorf("concurrent and sequential loads differ: %+v", d)
+			}
+		})
+	}
+}
+
+func Test_parallel_error(t *testing.T) {
+	var calls int64
+	err := parallel(context.Background(), 4, 100, func(ctx context.Context, i int) error {
+		atomic.AddInt64(&calls, 1)
+		if i == 3 {
+			return errors.New("boom")
+		}
+		<-ctx.Done()
+		return ctx.Err()
+	})
+	if err == nil || err.Error() != "boom" {
+		t.Errorf("err = %v, want boom", err)
+	}
+	if c := atomic.LoadInt64(&calls); c > 8 {
+		t.Errorf("%d calls after the error, want the remaining tables skipped", c)
+	}
+}
+
+func Test_parallel_canceled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	err := parallel(ctx, 2, 10, func(ctx context.Context, i int) error {
+		if err := ctx.Err(); err != nil {
+			return fmt.Errorf("table %d: %w", i, err)
+		}
+		return nil
+	})
+	if err == nil || !strings.HasPrefix(err.Error(), "table ") || !errors.Is(err, context.Canceled) {
+		t.Errorf("err = %v, want the table stopped by the cancellation", err)
+	}
+}
+
+func Test_LoadTableDef_timeout(t *testing.T) {
+	all := func(string) bool { return true }
+	for _, name := range []string{"mysql", "postgres"} {
+		for mode, opts := range map[string]Options{
+			"bulk":      {},
+			"per_table": {Filter: all, Concurrency: 2},
+		} {
+			t.Run(name+"/"+mode, func(t *testing.T) {
+				conn := newFakeDB(name, 20, 30*time.Millisecond).open()
+				defer conn.Close()
+				// the table list is loaded, the deadline hits a column query
+				ctx, cancel := context.WithTimeout(context.Background(), 75*time.Millisecond)
+				defer cancel()
+				_, err := newFakePlanter(name, conn, opts).LoadTableDef(ctx)
+				if !errors.Is(err, context.DeadlineExceeded) {
+					t.Fatalf("err = %v, want deadline exceeded", err)
+				}
+				if !strings.Contains(err.Error(), "t0000") && !strings.Contains(err.Error(), "t0001") {
+					t.Errorf("error does not name a table: %v", err)
+				}
+			})
+		}
+	}
+}
+
+// BenchmarkLoadTableDef compares bulk loading with per-table queries on a
+// catalog answering every query after 100µs
+func BenchmarkLoadTableDef(b *testing.B) {
+	const n = 300
+	all := func(string) bool { return true }
+	for _, name := range []string{"mysql", "postgres"} {
+		for _, bc := range []struct {
+			mode string
+			opts Options
+		}{
+			{"bulk", Options{}},
+			{"per_table", Options{Filter: all}},
+			{"per_table_concurrency_8", Options{Filter: all, Concurrency: 8}},
+		} {
+			b.Run(name+"/"+bc.mode, func(b *testing.B) {
+				db := newFakeDB(name, n, 100*time.Microsecond)
+				conn := db.open()
+				defer conn.Close()
+				b.ResetTimer()
+				for i := 0; i < b.N; i++ {
+					if _, err := newFakePlanter(name, conn, bc.opts).LoadTableDef(context.Background()); err != nil {
+						b.Fatal(err)
+					}
+				}
+				b.ReportMetric(float64(atomic.LoadInt64(&db.queries))/float64(b.N), "queries/op")
+			})
+		}
+	}
+}
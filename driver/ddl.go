@@ -0,0 +1,1164 @@
+package driver
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/maocatooo/planter/schema"
+	"github.com/pkg/errors"
+)
+
+// ddl loads table definitions from a schema file (schema.sql, mysqldump --no-data,
+// pg_dump --schema-only) without a database connection
+type ddl struct {
+	src []byte
+}
+
+func NewDDL() Planter {
+	return &ddl{}
+}
+
+// OpenDB read the DDL file, "-" reads stdin
+func (m *ddl) OpenDB(connStr string) error {
+	var (
+		src []byte
+		err error
+	)
+	if connStr == "-" {
+		src, err = io.ReadAll(os.Stdin)
+	} else {
+		src, err = os.ReadFile(connStr)
+	}
+	if err != nil {
+		return errors.Wrap(err, "failed to read ddl file")
+	}
+	m.src = src
+	return nil
+}
+
+// LoadTableDef parse DDL table definition
+func (m *ddl) LoadTableDef(ctx context.Context) ([]*schema.Table, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	tbls, err := ParseDDL(m.src)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to parse ddl")
+	}
+	return tbls, nil
+}
+
+type ddlTokenKind int
+
+const (
+	ddlWord        ddlTokenKind = iota // bare identifier, keyword or number
+	ddlQuotedIdent                     // `name` or "name"
+	ddlString                          // 'text' or $tag$text$tag$
+	ddlPunct                           // ( ) , . = and friends
+	ddlEnd                             // statement delimiter
+)
+
+type ddlToken struct {
+	kind ddlTokenKind
+	text string
+	// dquote "text" is an identifier in PostgreSQL but a string in MySQL
+	dquote bool
+}
+
+func (t ddlToken) is(word string) bool {
+	return t.kind == ddlWord && strings.EqualFold(t.text, word)
+}
+
+func (t ddlToken) punct(p string) bool {
+	return t.kind == ddlPunct && t.text == p
+}
+
+// ddlLexer splits DDL source into tokens, comments are dropped
+type ddlLexer struct {
+	src       string
+	pos       int
+	delimiter string
+	// backslash escapes inside string literals (MySQL)
+	backslash bool
+	lineStart bool
+	tokens    []ddlToken
+}
+
+func lexDDL(src string) ([]ddlToken, error) {
+	lx := &ddlLexer{
+		src:       src,
+		delimiter: ";",
+		backslash: isMySQLDDL(src),
+		lineStart: true,
+	}
+	if err := lx.run(); err != nil {
+		return nil, err
+	}
+	return lx.tokens, nil
+}
+
+// isMySQLDDL guess the dialect of a DDL file, it only affects string escapes
+func isMySQLDDL(src string) bool {
+	upper := strings.ToUpper(src)
+	return strings.Contains(src, "`") ||
+		strings.Contains(upper, "ENGINE=") ||
+		strings.Contains(upper, "AUTO_INCREMENT")
+}
+
+func (lx *ddlLexer) emit(kind ddlTokenKind, text string, dquote bool) {
+	lx.tokens = append(lx.tokens, ddlToken{kind: kind, text: text, dquote: dquote})
+}
+
+func (lx *ddlLexer) run() error {
+	for lx.pos < len(lx.src) {
+		if lx.lineStart && lx.directive() {
+			continue
+		}
+		r, size := utf8.DecodeRuneInString(lx.src[lx.pos:])
+		rest := lx.src[lx.pos:]
+		switch {
+		case r == '\n':
+			lx.pos += size
+			lx.lineStart = true
+			continue
+		case unicode.IsSpace(r):
+			lx.pos += size
+			continue
+		case strings.HasPrefix(rest, lx.delimiter):
+			lx.emit(ddlEnd, lx.delimiter, false)
+			lx.pos += len(lx.delimiter)
+		case strings.HasPrefix(rest, "--") || r == '#':
+			if i := strings.IndexByte(rest, '\n'); i >= 0 {
+				lx.pos += i
+			} else {
+				lx.pos = len(lx.src)
+			}
+		case strings.HasPrefix(rest, "/*"):
+			i := strings.Index(rest[2:], "*/")
+			if i < 0 {
+				return errors.New("unterminated comment")
+			}
+			lx.pos += i + 4
+		case r == '\'':
+			s, err := lx.quoted('\'', lx.backslash)
+			if err != nil {
+				return err
+			}
+			lx.emit(ddlString, s, false)
+		case r == '"':
+			s, err := lx.quoted('"', lx.backslash)
+			if err != nil {
+				return err
+			}
+			lx.emit(ddlQuotedIdent, s, true)
+		case r == '`':
+			s, err := lx.quoted('`', false)
+			if err != nil {
+				return err
+			}
+			lx.emit(ddlQuotedIdent, s, false)
+		case r == '$' && lx.dollarQuoted():
+		case isDDLWordRune(r):
+			start := lx.pos
+			for lx.pos < len(lx.src) {
+				r, size := utf8.DecodeRuneInString(lx.src[lx.pos:])
+				if !isDDLWordRune(r) && r != '$' {
+					break
+				}
+				lx.pos += size
+			}
+			lx.emit(ddlWord, lx.src[start:lx.pos], false)
+		default:
+			lx.emit(ddlPunct, string(r), false)
+			lx.pos += size
+		}
+		lx.lineStart = false
+	}
+	return nil
+}
+
+// directive handle mysql client DELIMITER lines used around triggers and routines
+func (lx *ddlLexer) directive() bool {
+	rest := lx.src[lx.pos:]
+	line := rest
+	if i := strings.IndexByte(rest, '\n'); i >= 0 {
+		line = rest[:i]
+	}
+	fields := strings.Fields(line)
+	if len(fields) != 2 || !strings.EqualFold(fields[0], "DELIMITER") {
+		return false
+	}
+	lx.delimiter = fields[1]
+	lx.pos += len(line)
+	lx.emit(ddlEnd, lx.delimiter, false)
+	return true
+}
+
+func (lx *ddlLexer) quoted(q byte, backslash bool) (string, error) {
+	var sb strings.Builder
+	i := lx.pos + 1
+	for i < len(lx.src) {
+		c := lx.src[i]
+		switch {
+		case backslash && c == '\\' && i+1 < len(lx.src):
+			sb.WriteByte(unescapeDDL(lx.src[i+1]))
+			i += 2
+		case c == q && i+1 < len(lx.src) && lx.src[i+1] == q:
+			sb.WriteByte(q)
+			i += 2
+		case c == q:
+			lx.pos = i + 1
+			return sb.String(), nil
+		default:
+			sb.WriteByte(c)
+			i++
+		}
+	}
+	return "", errors.Errorf("unterminated %c quote", q)
+}
+
+func unescapeDDL(c byte) byte {
+	switch c {
+	case 'n':
+		return '\n'
+	case 't':
+		return '\t'
+	case 'r':
+		return '\r'
+	case '0':
+		return 0
+	default:
+		return c
+	}
+}
+
+// dollarQuoted lex PostgreSQL $tag$ ... $tag$ strings
+func (lx *ddlLexer) dollarQuoted() bool {
+	rest := lx.src[lx.pos:]
+	end := strings.IndexByte(rest[1:], '$')
+	if end < 0 {
+		return false
+	}
+	tag := rest[:end+2]
+	for _, r := range tag[1 : len(tag)-1] {
+		if !isDDLWordRune(r) {
+			return false
+		}
+	}
+	body := rest[len(tag):]
+	i := strings.Index(body, tag)
+	if i < 0 {
+		return false
+	}
+	lx.emit(ddlString, body[:i], false)
+	lx.pos += len(tag) + i + len(tag)
+	return true
+}
+
+func isDDLWordRune(r rune) bool {
+	return r == '_' || unicode.IsLetter(r) || unicode.IsDigit(r)
+}
+
+// ddlParser walks one statement worth of tokens
+type ddlParser struct {
+	toks []ddlToken
+	pos  int
+}
+
+func (p *ddlParser) eof() bool {
+	return p.pos >= len(p.toks)
+}
+
+func (p *ddlParser) peek() ddlToken {
+	if p.eof() {
+		return ddlToken{kind: ddlEnd}
+	}
+	return p.toks[p.pos]
+}
+
+func (p *ddlParser) next() ddlToken {
+	t := p.peek()
+	if !p.eof() {
+		p.pos++
+	}
+	return t
+}
+
+// accept consume the given sequence of keywords if present
+func (p *ddlParser) accept(words ...string) bool {
+	for i, w := range words {
+		if p.pos+i >= len(p.toks) || !p.toks[p.pos+i].is(w) {
+			return false
+		}
+	}
+	p.pos += len(words)
+	return true
+}
+
+func (p *ddlParser) acceptPunct(s string) bool {
+	if p.peek().punct(s) {
+		p.pos++
+		return true
+	}
+	return false
+}
+
+// skip consume one token, or a whole parenthesized group
+func (p *ddlParser) skip() {
+	if !p.peek().punct("(") {
+		p.next()
+		return
+	}
+	depth := 0
+	for !p.eof() {
+		t := p.next()
+		switch {
+		case t.punct("("):
+			depth++
+		case t.punct(")"):
+			depth--
+			if depth == 0 {
+				return
+			}
+		}
+	}
+}
+
+func (p *ddlParser) ident() (string, error) {
+	t := p.next()
+	switch t.kind {
+	case ddlWord, ddlQuotedIdent:
+		return t.text, nil
+	}
+	return "", errors.Errorf("expected identifier, got %q", t.text)
+}
+
+// qualifiedName parse schema.table.column style names and return the parts
+func (p *ddlParser) qualifiedName() ([]string, error) {
+	var parts []string
+	for {
+		name, err := p.ident()
+		if err != nil {
+			return nil, err
+		}
+		parts = append(parts, name)
+		if !p.acceptPunct(".") {
+			return parts, nil
+		}
+	}
+}
+
+func (p *ddlParser) tableName() (string, error) {
+	parts, err := p.qualifiedName()
+	if err != nil {
+		return "", err
+	}
+	return parts[len(parts)-1], nil
+}
+
+func (p *ddlParser) stringLit() (string, error) {
+	t := p.next()
+	if t.kind == ddlString || t.dquote {
+		return t.text, nil
+	}
+	return "", errors.Errorf("expected string, got %q", t.text)
+}
+
+// identList parse ( a, b, c ), index prefix lengths and sort orders are dropped
+func (p *ddlParser) identList() ([]string, error) {
+	if !p.acceptPunct("(") {
+		return nil, errors.Errorf("expected (, got %q", p.peek().text)
+	}
+	var names []string
+	for {
+		name, err := p.ident()
+		if err != nil {
+			return nil, err
+		}
+		names = append(names, name)
+		for !p.eof() && !p.peek().punct(",") && !p.peek().punct(")") {
+			p.skip()
+		}
+		if p.acceptPunct(")") {
+			return names, nil
+		}
+		if !p.acceptPunct(",") {
+			return nil, errors.New("unterminated column list")
+		}
+	}
+}
+
+// splitTopLevel split tokens by commas outside of parentheses
+func splitTopLevel(toks []ddlToken) [][]ddlToken {
+	var (
+		parts [][]ddlToken
+		depth int
+		start int
+	)
+	for i, t := range toks {
+		switch {
+		case t.punct("("):
+			depth++
+		case t.punct(")"):
+			depth--
+		case t.punct(",") && depth == 0:
+			parts = append(parts, toks[start:i])
+			start = i + 1
+		}
+	}
+	return append(parts, toks[start:])
+}
+
+type ddlForeignKey struct {
+	name        string
+	sourceTable string
+	sourceCols  []string
+	targetTable string
+	targetCols  []string
+}
+
+type ddlSchema struct {
+	tbls []*schema.Table
+	fks  []*ddlForeignKey
+}
+
+func (s *ddlSchema) table(name string) (*schema.Table, error) {
+	tbl, found := schema.FindTableByName(s.tbls, name)
+	if !found {
+		return nil, errors.Errorf("%s not found", name)
+	}
+	return tbl, nil
+}
+
+func (s *ddlSchema) column(tbl *schema.Table, name string) (*schema.Column, error) {
+	for _, col := range tbl.Columns {
+		if col.Name == name {
+			return col, nil
+		}
+	}
+	return nil, errors.Errorf("%s.%s not found", tbl.Name, name)
+}
+
+// ddlIndex index on cols, unnamed indexes are named like PostgreSQL does,
+// the method defaults to btree
+func ddlIndex(tbl *schema.Table, name string, cols []string, unique bool, method string) *schema.Index {
+	if name == "" {
+		name = fmt.Sprintf("%s_%s_idx", tbl.Name, strings.Join(cols, "_"))
+	}
+	if method == "" {
+		method = "btree"
+	}
+	return &schema.Index{Name: name, ColumnNames: cols, Unique: unique, Method: method}
+}
+
+// indexMethod parse an optional USING method
+func (p *ddlParser) indexMethod() string {
+	if !p.accept("USING") {
+		return ""
+	}
+	return strings.ToLower(p.next().text)
+}
+
+// indexColumns parse the key list of an index, plain columns keep their name
+// plus the MySQL prefix length, e.g. name(10), expressions are kept as written
+func (p *ddlParser) indexColumns() ([]string, error) {
+	body, err := p.group()
+	if err != nil {
+		return nil, err
+	}
+	var cols []string
+	for _, part := range splitTopLevel(body) {
+		if len(part) == 0 {
+			return nil, errors.New("empty index column")
+		}
+		cols = append(cols, indexColumn(part))
+	}
+	return cols, nil
+}
+
+func indexColumn(part []ddlToken) string {
+	first, rest := part[0], part[1:]
+	if first.kind != ddlWord && first.kind != ddlQuotedIdent {
+		return formatTokens(part, false)
+	}
+	name := first.text
+	if len(rest) >= 3 && rest[0].punct("(") && rest[2].punct(")") && rest[1].kind == ddlWord &&
+		strings.Trim(rest[1].text, "0123456789") == "" {
+		name += "(" + rest[1].text + ")"
+		rest = rest[3:]
+	}
+	for _, t := range rest {
+		if !t.is("ASC") && !t.is("DESC") && !t.is("NULLS") && !t.is("FIRST") && !t.is("LAST") {
+			return formatTokens(part, false)
+		}
+	}
+	return name
+}
+
+// createIndex parse CREATE INDEX, indexes of unknown tables are ignored
+func (s *ddlSchema) createIndex(p *ddlParser, unique bool, method string) error {
+	p.accept("CONCURRENTLY")
+	p.accept("IF", "NOT", "EXISTS")
+	var name string
+	if !p.peek().is("ON") {
+		var err error
+		if name, err = p.ident(); err != nil {
+			return err
+		}
+	}
+	if m := p.indexMethod(); m != "" {
+		method = m
+	}
+	if !p.accept("ON") {
+		return errors.Errorf("expected ON in index %s", name)
+	}
+	p.accept("ONLY")
+	tblName, err := p.tableName()
+	if err != nil {
+		return err
+	}
+	if m := p.indexMethod(); m != "" {
+		method = m
+	}
+	cols, err := p.indexColumns()
+	if err != nil {
+		return err
+	}
+	tbl, found := schema.FindTableByName(s.tbls, tblName)
+	if !found {
+		return nil
+	}
+	ix := ddlIndex(tbl, name, cols, unique, method)
+	for !p.eof() {
+		switch {
+		case p.accept("USING"):
+			ix.Method = strings.ToLower(p.next().text)
+		case p.accept("WHERE"):
+			ix.Predicate = formatTokens(p.toks[p.pos:], false)
+			p.pos = len(p.toks)
+		default:
+			p.skip()
+		}
+	}
+	tbl.Indexes = append(tbl.Indexes, ix)
+	return nil
+}
+
+// ddlUniqueKey unique key on cols, unnamed keys are named like PostgreSQL does
+func ddlUniqueKey(tbl *schema.Table, name string, cols []string) *schema.UniqueKey {
+	if name == "" {
+		name = fmt.Sprintf("%s_%s_key", tbl.Name, strings.Join(cols, "_"))
+	}
+	return &schema.UniqueKey{ConstraintName: name, ColumnNames: cols}
+}
+
+func (s *ddlSchema) setPrimaryKey(tbl *schema.Table, cols []string) error {
+	for _, name := range cols {
+		col, err := s.column(tbl, name)
+		if err != nil {
+			return err
+		}
+		col.IsPrimaryKey = true
+		col.NotNull = true
+	}
+	return nil
+}
+
+// ParseDDL parse CREATE TABLE, ALTER TABLE and COMMENT statements into tables,
+// other statements are ignored
+func ParseDDL(src []byte) ([]*schema.Table, error) {
+	toks, err := lexDDL(string(src))
+	if err != nil {
+		return nil, err
+	}
+	s := &ddlSchema{}
+	start := 0
+	for i := 0; i <= len(toks); i++ {
+		if i < len(toks) && toks[i].kind != ddlEnd {
+			continue
+		}
+		if i > start {
+			p := &ddlParser{toks: toks[start:i]}
+			if err := s.statement(p); err != nil {
+				return nil, errors.Wrapf(err, "statement %q", stmtPreview(toks[start:i]))
+			}
+		}
+		start = i + 1
+	}
+	if err := s.linkForeignKeys(); err != nil {
+		return nil, err
+	}
+	sort.SliceStable(s.tbls, func(i, j int) bool {
+		return s.tbls[i].Name < s.tbls[j].Name
+	})
+	return s.tbls, nil
+}
+
+func stmtPreview(toks []ddlToken) string {
+	var words []string
+	for _, t := range toks {
+		words = append(words, t.text)
+		if len(words) == 6 {
+			break
+		}
+	}
+	return strings.Join(words, " ")
+}
+
+func (s *ddlSchema) statement(p *ddlParser) error {
+	switch {
+	case p.accept("CREATE"):
+		unique := p.accept("UNIQUE")
+		method := ""
+		switch {
+		case p.accept("FULLTEXT"):
+			method = "fulltext"
+		case p.accept("SPATIAL"):
+			method = "spatial"
+		}
+		if p.accept("INDEX") {
+			return s.createIndex(p, unique, method)
+		}
+		for p.accept("GLOBAL") || p.accept("LOCAL") || p.accept("TEMPORARY") ||
+			p.accept("TEMP") || p.accept("UNLOGGED") {
+		}
+		if !p.accept("TABLE") {
+			return nil
+		}
+		return s.createTable(p)
+	case p.accept("ALTER", "TABLE"):
+		return s.alterTable(p)
+	case p.accept("COMMENT", "ON"):
+		return s.commentOn(p)
+	}
+	return nil
+}
+
+func (s *ddlSchema) createTable(p *ddlParser) error {
+	p.accept("IF", "NOT", "EXISTS")
+	name, err := p.tableName()
+	if err != nil {
+		return err
+	}
+	// CREATE TABLE ... AS SELECT / PARTITION OF / LIKE are not table definitions we can read
+	if !p.peek().punct("(") {
+		return nil
+	}
+	body, err := p.group()
+	if err != nil {
+		return err
+	}
+	tbl := &schema.Table{Name: name}
+	s.tbls = append(s.tbls, tbl)
+	var constraints [][]ddlToken
+	for _, elem := range splitTopLevel(body) {
+		if len(elem) == 0 {
+			continue
+		}
+		if isTableConstraint(elem) {
+			constraints = append(constraints, elem)
+			continue
+		}
+		if err := s.columnDef(tbl, &ddlParser{toks: elem}); err != nil {
+			return err
+		}
+	}
+	for _, elem := range constraints {
+		if err := s.tableConstraint(tbl, &ddlParser{toks: elem}); err != nil {
+			return err
+		}
+	}
+	return s.tableOptions(tbl, p)
+}
+
+// group return the tokens inside the next parenthesized group
+func (p *ddlParser) group() ([]ddlToken, error) {
+	start := p.pos
+	p.skip()
+	end := p.pos
+	if end-start < 2 || !p.toks[end-1].punct(")") {
+		return nil, errors.New("unbalanced parentheses")
+	}
+	return p.toks[start+1 : end-1], nil
+}
+
+func isTableConstraint(elem []ddlToken) bool {
+	first := elem[0]
+	for _, w := range []string{
+		"CONSTRAINT", "PRIMARY", "FOREIGN", "UNIQUE", "KEY", "INDEX",
+		"FULLTEXT", "SPATIAL", "CHECK", "EXCLUDE", "LIKE", "PERIOD",
+	} {
+		if first.is(w) {
+			return true
+		}
+	}
+	return false
+}
+
+// tableOptions read MySQL table options after the column list, only COMMENT is used
+func (s *ddlSchema) tableOptions(tbl *schema.Table, p *ddlParser) error {
+	for !p.eof() {
+		if p.accept("COMMENT") {
+			p.acceptPunct("=")
+			c, err := p.stringLit()
+			if err != nil {
+				return err
+			}
+			tbl.Comment = ddlComment(c)
+			continue
+		}
+		p.skip()
+	}
+	return nil
+}
+
+func ddlComment(s string) sql.NullString {
+	return sql.NullString{String: stripCommentSuffix(s), Valid: true}
+}
+
+// ddlColumnStop keywords ending the data type of a column definition
+var ddlColumnStop = map[string]bool{
+	"NOT": true, "NULL": true, "PRIMARY": true, "REFERENCES": true, "COMMENT": true,
+	"DEFAULT": true, "AUTO_INCREMENT": true, "UNIQUE": true, "CONSTRAINT": true,
+	"GENERATED": true, "CHECK": true, "COLLATE": true, "CHARSET": true, "AS": true,
+	"ON": true, "KEY": true, "IDENTITY": true, "VISIBLE": true, "INVISIBLE": true,
+}
+
+func isColumnStop(p *ddlParser) bool {
+	t := p.peek()
+	if t.kind != ddlWord {
+		return false
+	}
+	if t.is("CHARACTER") {
+		return p.pos+1 < len(p.toks) && p.toks[p.pos+1].is("SET")
+	}
+	return ddlColumnStop[strings.ToUpper(t.text)]
+}
+
+func (s *ddlSchema) columnDef(tbl *schema.Table, p *ddlParser) error {
+	name, err := p.ident()
+	if err != nil {
+		return err
+	}
+	col := &schema.Column{
+		FieldOrdinal: len(tbl.Columns) + 1,
+		Name:         name,
+	}
+	col.DataType = p.dataType()
+	col.DDLType = col.DataType
+	tbl.Columns = append(tbl.Columns, col)
+
+	var constraintName string
+	for !p.eof() {
+		switch {
+		case p.accept("NOT", "NULL"):
+			col.NotNull = true
+		case p.accept("PRIMARY", "KEY"):
+			col.IsPrimaryKey = true
+			col.NotNull = true
+		case p.accept("UNIQUE"):
+			p.accept("KEY")
+			uk := ddlUniqueKey(tbl, constraintName, []string{col.Name})
+			if err := addUniqueKey(tbl, uk); err != nil {
+				return err
+			}
+			tbl.Indexes = append(tbl.Indexes, ddlIndex(tbl, uk.ConstraintName, uk.ColumnNames, true, ""))
+		case p.accept("CONSTRAINT"):
+			if constraintName, err = p.ident(); err != nil {
+				return err
+			}
+			continue
+		case p.accept("REFERENCES"):
+			fk, err := p.references(constraintName)
+			if err != nil {
+				return err
+			}
+			fk.sourceTable = tbl.Name
+			fk.sourceCols = []string{col.Name}
+			s.fks = append(s.fks, fk)
+		case p.accept("COMMENT"):
+			c, err := p.stringLit()
+			if err != nil {
+				return err
+			}
+			col.Comment = ddlComment(c)
+		case p.accept("GENERATED", "BY", "DEFAULT"):
+			// identity column, the IDENTITY keyword and its options are skipped
+		case p.accept("DEFAULT"):
+			col.Default = defaultValue(p.expr())
+		case p.accept("ON", "UPDATE"):
+			addExtra(col, "on update "+formatTokens(p.expr(), false))
+		case p.accept("AUTO_INCREMENT"):
+			addExtra(col, "auto_increment")
+		case p.accept("GENERATED", "ALWAYS", "AS"), p.accept("AS"):
+			if !p.peek().punct("(") {
+				// GENERATED ALWAYS AS IDENTITY
+				continue
+			}
+			p.skip()
+			kind := "VIRTUAL"
+			if p.accept("STORED") {
+				kind = "STORED"
+			} else {
+				p.accept("VIRTUAL")
+			}
+			addExtra(col, kind+" GENERATED")
+		default:
+			p.skip()
+		}
+		constraintName = ""
+	}
+	return nil
+}
+
+// dataType read the type of a column definition, normalized to lower case
+// without blanks around parentheses, e.g. varchar(255), decimal(10,2)
+func (p *ddlParser) dataType() string {
+	start, depth := p.pos, 0
+	for !p.eof() {
+		if depth == 0 && isColumnStop(p) {
+			break
+		}
+		t := p.next()
+		switch {
+		case t.punct("("):
+			depth++
+		case t.punct(")"):
+			depth--
+		}
+	}
+	return formatTokens(p.toks[start:p.pos], true)
+}
+
+// expr read an expression up to the next column clause, e.g. the value of
+// DEFAULT or ON UPDATE
+func (p *ddlParser) expr() []ddlToken {
+	start := p.pos
+	p.skip()
+	for !p.eof() && !isColumnStop(p) {
+		p.skip()
+	}
+	return p.toks[start:p.pos]
+}
+
+// formatTokens join tokens back into SQL text, lower casing the words outside
+// parentheses when lower is set
+func formatTokens(toks []ddlToken, lower bool) string {
+	var sb strings.Builder
+	depth := 0
+	for _, t := range toks {
+		switch {
+		case t.punct("("):
+			depth++
+		case t.punct(")"):
+			depth--
+		}
+		text := t.text
+		switch t.kind {
+		case ddlWord:
+			if lower && depth == 0 {
+				text = strings.ToLower(text)
+			}
+		case ddlString:
+			text = "'" + strings.ReplaceAll(text, "'", "''") + "'"
+		case ddlQuotedIdent:
+			text = fmt.Sprintf("%q", text)
+		}
+		if sb.Len() > 0 && t.kind != ddlPunct && !strings.HasSuffix(sb.String(), "(") &&
+			!strings.HasSuffix(sb.String(), ",") && !strings.HasSuffix(sb.String(), "[") &&
+			!strings.HasSuffix(sb.String(), ":") {
+			sb.WriteByte(' ')
+		}
+		sb.WriteString(text)
+	}
+	return sb.String()
+}
+
+// defaultValue a string literal is stored unquoted like information_schema
+// does, DEFAULT NULL is no default
+func defaultValue(toks []ddlToken) sql.NullString {
+	if len(toks) == 1 {
+		switch {
+		case toks[0].kind == ddlString:
+			return sql.NullString{String: toks[0].text, Valid: true}
+		case toks[0].is("NULL"):
+			return sql.NullString{}
+		}
+	}
+	return sql.NullString{String: formatTokens(toks, false), Valid: len(toks) > 0}
+}
+
+func addExtra(col *schema.Column, extra string) {
+	if col.Extra != "" {
+		col.Extra += " "
+	}
+	col.Extra += extra
+}
+
+// references parse REFERENCES table [(cols)] and drop ON DELETE/MATCH options
+func (p *ddlParser) references(name string) (*ddlForeignKey, error) {
+	target, err := p.tableName()
+	if err != nil {
+		return nil, err
+	}
+	fk := &ddlForeignKey{
+		name:        name,
+		targetTable: target,
+	}
+	if p.peek().punct("(") {
+		if fk.targetCols, err = p.identList(); err != nil {
+			return nil, err
+		}
+	}
+	for p.accept("ON", "DELETE") || p.accept("ON", "UPDATE") || p.accept("MATCH") {
+		switch {
+		case p.accept("SET", "NULL"), p.accept("SET", "DEFAULT"), p.accept("NO", "ACTION"):
+		default:
+			p.next()
+		}
+	}
+	return fk, nil
+}
+
+func (s *ddlSchema) tableConstraint(tbl *schema.Table, p *ddlParser) error {
+	var (
+		name string
+		err  error
+	)
+	if p.accept("CONSTRAINT") {
+		if !p.peek().is("PRIMARY") && !p.peek().is("FOREIGN") &&
+			!p.peek().is("UNIQUE") && !p.peek().is("CHECK") {
+			if name, err = p.ident(); err != nil {
+				return err
+			}
+		}
+	}
+	switch {
+	case p.accept("PRIMARY", "KEY"):
+		// MySQL allows an index type between KEY and the column list
+		for !p.eof() && !p.peek().punct("(") {
+			p.next()
+		}
+		cols, err := p.identList()
+		if err != nil {
+			return err
+		}
+		return s.setPrimaryKey(tbl, cols)
+	case p.accept("UNIQUE"):
+		_ = p.accept("KEY") || p.accept("INDEX")
+		p.accept("NULLS", "NOT", "DISTINCT")
+		if !p.peek().punct("(") {
+			// MySQL index name
+			idx, err := p.ident()
+			if err != nil {
+				return err
+			}
+			if name == "" {
+				name = idx
+			}
+		}
+		// MySQL allows an index type before the column list
+		for !p.eof() && !p.peek().punct("(") {
+			p.next()
+		}
+		cols, err := p.indexColumns()
+		if err != nil {
+			return err
+		}
+		uk := ddlUniqueKey(tbl, name, cols)
+		if err := addUniqueKey(tbl, uk); err != nil {
+			return err
+		}
+		tbl.Indexes = append(tbl.Indexes, ddlIndex(tbl, uk.ConstraintName, cols, true, p.indexMethod()))
+	case p.accept("KEY"), p.accept("INDEX"), p.peek().is("FULLTEXT"), p.peek().is("SPATIAL"):
+		method := ""
+		if p.accept("FULLTEXT") || p.accept("SPATIAL") {
+			method = strings.ToLower(p.toks[p.pos-1].text)
+			_ = p.accept("KEY") || p.accept("INDEX")
+		}
+		if !p.peek().punct("(") && !p.peek().is("USING") {
+			if name, err = p.ident(); err != nil {
+				return err
+			}
+		}
+		if m := p.indexMethod(); m != "" {
+			method = m
+		}
+		cols, err := p.indexColumns()
+		if err != nil {
+			return err
+		}
+		if m := p.indexMethod(); m != "" {
+			method = m
+		}
+		tbl.Indexes = append(tbl.Indexes, ddlIndex(tbl, name, cols, false, method))
+	case p.accept("FOREIGN", "KEY"):
+		if !p.peek().punct("(") {
+			// MySQL index name
+			if _, err := p.ident(); err != nil {
+				return err
+			}
+		}
+		cols, err := p.identList()
+		if err != nil {
+			return err
+		}
+		if !p.accept("REFERENCES") {
+			return errors.Errorf("expected REFERENCES in foreign key of %s", tbl.Name)
+		}
+		fk, err := p.references(name)
+		if err != nil {
+			return err
+		}
+		fk.sourceTable = tbl.Name
+		fk.sourceCols = cols
+		s.fks = append(s.fks, fk)
+	}
+	return nil
+}
+
+func (s *ddlSchema) alterTable(p *ddlParser) error {
+	p.accept("IF", "EXISTS")
+	p.accept("ONLY")
+	name, err := p.tableName()
+	if err != nil {
+		return err
+	}
+	tbl, found := schema.FindTableByName(s.tbls, name)
+	if !found {
+		return nil
+	}
+	for _, action := range splitTopLevel(p.toks[p.pos:]) {
+		ap := &ddlParser{toks: action}
+		switch {
+		case ap.accept("ADD"):
+			if ap.accept("COLUMN") || (!ap.eof() && !isTableConstraint(ap.toks[ap.pos:])) {
+				ap.accept("IF", "NOT", "EXISTS")
+				if err := s.columnDef(tbl, ap); err != nil {
+					return err
+				}
+				continue
+			}
+			if err := s.tableConstraint(tbl, ap); err != nil {
+				return err
+			}
+		case ap.accept("COMMENT"):
+			ap.acceptPunct("=")
+			c, err := ap.stringLit()
+			if err != nil {
+				return err
+			}
+			tbl.Comment = ddlComment(c)
+		}
+	}
+	return nil
+}
+
+// commentOn parse PostgreSQL COMMENT ON TABLE/COLUMN ... IS '...'
+func (s *ddlSchema) commentOn(p *ddlParser) error {
+	var isColumn bool
+	switch {
+	case p.accept("TABLE"):
+	case p.accept("COLUMN"):
+		isColumn = true
+	default:
+		return nil
+	}
+	parts, err := p.qualifiedName()
+	if err != nil {
+		return err
+	}
+	if !p.accept("IS") {
+		return errors.New("expected IS")
+	}
+	var comment sql.NullString
+	if !p.accept("NULL") {
+		c, err := p.stringLit()
+		if err != nil {
+			return err
+		}
+		comment = ddlComment(c)
+	}
+	if !isColumn {
+		if tbl, found := schema.FindTableByName(s.tbls, parts[len(parts)-1]); found {
+			tbl.Comment = comment
+		}
+		return nil
+	}
+	if len(parts) < 2 {
+		return errors.Errorf("column comment without table: %s", parts[0])
+	}
+	if col, found := schema.FindColumnByName(s.tbls, parts[len(parts)-2], parts[len(parts)-1]); found {
+		col.Comment = comment
+	}
+	return nil
+}
+
+// linkForeignKeys resolve the collected foreign keys once every table is known
+func (s *ddlSchema) linkForeignKeys() error {
+	for _, d := range s.fks {
+		sourceTbl, err := s.table(d.sourceTable)
+		if err != nil {
+			return err
+		}
+		targetTbl, err := s.table(d.targetTable)
+		if err != nil {
+			return err
+		}
+		targetCols := d.targetCols
+		if len(targetCols) == 0 {
+			// REFERENCES t without a column list points at the primary key of t
+			for _, col := range targetTbl.Columns {
+				if col.IsPrimaryKey {
+					targetCols = append(targetCols, col.Name)
+				}
+			}
+		}
+		if len(targetCols) != len(d.sourceCols) || len(targetCols) == 0 {
+			return errors.Errorf("foreign key %s.(%s) does not match %s.(%s)",
+				d.sourceTable, strings.Join(d.sourceCols, ","), d.targetTable, strings.Join(targetCols, ","))
+		}
+		name := d.name
+		if name == "" {
+			name = fmt.Sprintf("%s_%s_fkey", d.sourceTable, strings.Join(d.sourceCols, "_"))
+		}
+		fk := &schema.ForeignKey{
+			ConstraintName:  name,
+			SourceTableName: sourceTbl.Name,
+			SourceTable:     sourceTbl,
+			TargetTableName: targetTbl.Name,
+			TargetTable:     targetTbl,
+		}
+		for i := range d.sourceCols {
+			sourceCol, err := s.column(sourceTbl, d.sourceCols[i])
+			if err != nil {
+				return err
+			}
+			targetCol, err := s.column(targetTbl, targetCols[i])
+			if err != nil {
+				return err
+			}
+			sourceCol.IsForeignKey = true
+			fk.Columns = append(fk.Columns, &schema.ForeignKeyColumn{
+				SourceColName: sourceCol.Name,
+				SourceColumn:  sourceCol,
+				TargetColName: targetCol.Name,
+				TargetColumn:  targetCol,
+			})
+		}
+		first := fk.Columns[0]
+		fk.SourceColName, fk.SourceColumn = first.SourceColName, first.SourceColumn
+		fk.TargetColName, fk.TargetColumn = first.TargetColName, first.TargetColumn
+		fk.IsSourceColPrimaryKey = first.SourceColumn.IsPrimaryKey
+		fk.IsTargetColPrimaryKey = first.TargetColumn.IsPrimaryKey
+		sourceTbl.ForeingKeys = append(sourceTbl.ForeingKeys, fk)
+	}
+	return nil
+}
@@ -0,0 +1,280 @@
+package driver
+
+import (
+	"os"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/maocatooo/planter/schema"
+)
+
+func Test_ParseDDL_mysql(t *testing.T) {
+	src, err := os.ReadFile("../test_mysql.sql")
+	if err != nil {
+		t.Fatal(err)
+	}
+	tbls, err := ParseDDL(src)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(tbls) != 4 {
+		t.Fatalf("tables = %d, want 4", len(tbls))
+	}
+
+	product, _ := schema.FindTableByName(tbls, "product")
+	if product.Comment.String != "商品" {
+		t.Errorf("product comment = %q", product.Comment.String)
+	}
+	price, _ := schema.FindColumnByName(tbls, "product", "price")
+	if price.DataType != "decimal(10,2)" || !price.NotNull || price.Comment.String != "商品价格" {
+		t.Errorf("price = %+v", price)
+	}
+	status, _ := schema.FindColumnByName(tbls, "order_info", "status")
+	if status.DataType != "enum('Pending','Processing','Shipped','Delivered')" {
+		t.Errorf("status type = %s", status.DataType)
+	}
+	email, _ := schema.FindColumnByName(tbls, "user", "email")
+	user, _ := schema.FindTableByName(tbls, "user")
+	if !email.IsUnique || !email.NotNull || len(user.UniqueKeys) != 1 || user.UniqueKeys[0].ConstraintName != "user_email_key" {
+		t.Errorf("user.email = %+v, unique keys %+v", email, user.UniqueKeys)
+	}
+	id, _ := schema.FindColumnByName(tbls, "user", "user_id")
+	if !id.IsPrimaryKey || !id.NotNull || id.Extra != "auto_increment" || id.Default.Valid {
+		t.Errorf("user.user_id = %+v", id)
+	}
+	if c, _ := schema.FindColumnByName(tbls, "product", "description"); c.NotNull {
+		t.Error("product.description should be nullable")
+	}
+	created, _ := schema.FindColumnByName(tbls, "product", "created_at")
+	if created.NotNull || created.Default.String != "CURRENT_TIMESTAMP" || created.Extra != "" {
+		t.Errorf("product.created_at = %+v", created)
+	}
+	updated, _ := schema.FindColumnByName(tbls, "product", "updated_at")
+	if updated.Default.String != "CURRENT_TIMESTAMP" || updated.Extra != "on update CURRENT_TIMESTAMP" || updated.Comment.String != "修改时间" {
+		t.Errorf("product.updated_at = %+v", updated)
+	}
+
+	cart, _ := schema.FindTableByName(tbls, "cart")
+	if len(cart.ForeingKeys) != 2 {
+		t.Fatalf("cart fks = %d, want 2", len(cart.ForeingKeys))
+	}
+	fk := cart.ForeingKeys[0]
+	if fk.TargetTableName != "user" || fk.TargetColName != "user_id" || !fk.SourceColumn.IsForeignKey {
+		t.Errorf("cart fk = %+v", fk)
+	}
+}
+
+const testPGDump = `
+--
+-- PostgreSQL database dump
+--
+SET statement_timeout = 0;
+SELECT pg_catalog.set_config('search_path', '', false);
+
+CREATE FUNCTION public.touch() RETURNS trigger
+    LANGUAGE plpgsql
+    AS $$
+BEGIN
+  NEW.updated_at = now();
+  RETURN NEW;
+END;
+$$;
+
+CREATE TABLE public.users (
+    id bigint NOT NULL,
+    "displayName" character varying(100),
+    created_at timestamp with time zone DEFAULT now() NOT NULL
+);
+
+COMMENT ON TABLE public.users IS 'registered users';
+COMMENT ON COLUMN public.users.id IS 'it''s the id';
+
+CREATE TABLE public.orders (
+    id bigint NOT NULL,
+    user_id bigint NOT NULL,
+    note text DEFAULT ''::text
+);
+
+CREATE TABLE public.order_lines (
+    order_id bigint NOT NULL REFERENCES public.orders,
+    line integer NOT NULL,
+    CONSTRAINT order_lines_pkey PRIMARY KEY (order_id, line)
+);
+
+ALTER TABLE ONLY public.users
+    ADD CONSTRAINT users_pkey PRIMARY KEY (id);
+ALTER TABLE ONLY public.orders
+    ADD CONSTRAINT orders_pkey PRIMARY KEY (id);
+ALTER TABLE ONLY public.users
+    ADD CONSTRAINT "users_displayName_key" UNIQUE ("displayName");
+ALTER TABLE ONLY public.orders
+    ADD CONSTRAINT orders_user_id_note_key UNIQUE (user_id, note);
+CREATE INDEX orders_user_id_idx ON public.orders USING btree (user_id) WHERE (note IS NOT NULL);
+CREATE INDEX orders_note_idx ON public.orders USING gin (to_tsvector('english'::regconfig, note));
+ALTER TABLE ONLY public.orders
+    ADD CONSTRAINT orders_user_id_fkey FOREIGN KEY (user_id) REFERENCES public.users(id) ON DELETE CASCADE;
+`
+
+func Test_ParseDDL_postgres(t *testing.T) {
+	tbls, err := ParseDDL([]byte(testPGDump))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var names []string
+	for _, tbl := range tbls {
+		names = append(names, tbl.Name)
+	}
+	if len(names) != 3 || names[0] != "order_lines" || names[1] != "orders" || names[2] != "users" {
+		t.Fatalf("tables = %v", names)
+	}
+
+	users, _ := schema.FindTableByName(tbls, "users")
+	if users.Comment.String != "registered users" {
+		t.Errorf("users comment = %q", users.Comment.String)
+	}
+	if c := users.Columns[0]; !c.IsPrimaryKey || c.Comment.String != "it's the id" {
+		t.Errorf("users.id = %+v", c)
+	}
+	if c := users.Columns[1]; c.Name != "displayName" || c.DataType != "character varying(100)" || !c.IsUnique {
+		t.Errorf("users.displayName = %+v", c)
+	}
+	if c := users.Columns[2]; c.DataType != "timestamp with time zone" || !c.NotNull || c.Default.String != "now()" {
+		t.Errorf("users.created_at = %+v", c)
+	}
+
+	if c, _ := schema.FindColumnByName(tbls, "orders", "note"); c.Default.String != "''::text" {
+		t.Errorf("orders.note default = %q", c.Default.String)
+	}
+
+	orders, _ := schema.FindTableByName(tbls, "orders")
+	if len(orders.UniqueKeys) != 1 || len(orders.UniqueKeys[0].Columns) != 2 || orders.Columns[1].IsUnique {
+		t.Errorf("orders unique keys = %+v", orders.UniqueKeys)
+	}
+	if len(orders.Indexes) != 3 {
+		t.Fatalf("orders indexes = %d, want 3", len(orders.Indexes))
+	}
+	if ix := orders.Indexes[0]; ix.Name != "orders_user_id_note_key" || !ix.Unique || len(ix.ColumnNames) != 2 {
+		t.Errorf("orders unique index = %+v", ix)
+	}
+	if ix := orders.Indexes[1]; ix.Unique || ix.Method != "btree" || ix.ColumnNames[0] != "user_id" || ix.Predicate != "(note IS NOT NULL)" {
+		t.Errorf("orders partial index = %+v", ix)
+	}
+	if ix := orders.Indexes[2]; ix.Method != "gin" || !strings.HasPrefix(ix.ColumnNames[0], "to_tsvector(") {
+		t.Errorf("orders gin index = %+v", ix)
+	}
+	if len(orders.ForeingKeys) != 1 {
+		t.Fatalf("orders fks = %d, want 1", len(orders.ForeingKeys))
+	}
+	if fk := orders.ForeingKeys[0]; fk.ConstraintName != "orders_user_id_fkey" || fk.TargetColumn != users.Columns[0] {
+		t.Errorf("orders fk = %+v", fk)
+	}
+
+	lines, _ := schema.FindTableByName(tbls, "order_lines")
+	if !lines.IsCompositePK() {
+		t.Error("order_lines should have a composite pk")
+	}
+	if fk := lines.ForeingKeys[0]; fk.TargetColName != "id" || fk.IsOneToOne() {
+		t.Errorf("order_lines fk = %+v", fk)
+	}
+}
+
+func Test_ParseDDL_error(t *testing.T) {
+	_, err := ParseDDL([]byte(`CREATE TABLE a (id int, b_id int REFERENCES b(id));`))
+	if err == nil {
+		t.Fatal("expected error for unknown referenced table")
+	}
+}
+
+func Test_ParseDDL_uniqueKey(t *testing.T) {
+	tbls, err := ParseDDL([]byte(`CREATE TABLE t (
+  a int NOT NULL,
+  b int NOT NULL,
+  c int CONSTRAINT t_c_uq UNIQUE,
+  UNIQUE KEY uk_a_b (a, b) USING BTREE
+);`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	uks := tbls[0].UniqueKeys
+	if len(uks) != 2 || uks[0].ConstraintName != "t_c_uq" || uks[1].ConstraintName != "uk_a_b" {
+		t.Fatalf("unique keys = %+v", uks)
+	}
+	cols := tbls[0].Columns
+	if cols[0].IsUnique || cols[1].IsUnique || !cols[2].IsUnique || uks[1].Columns[1] != cols[1] {
+		t.Errorf("columns = %+v %+v %+v", cols[0], cols[1], cols[2])
+	}
+}
+
+func Test_ParseDDL_index(t *testing.T) {
+	tbls, err := ParseDDL([]byte(`CREATE TABLE post (
+  id int NOT NULL AUTO_INCREMENT,
+  author_id int NOT NULL,
+  title varchar(255) NOT NULL,
+  body text,
+  PRIMARY KEY (id),
+  KEY idx_author (author_id, title(20) DESC),
+  FULLTEXT KEY ft_body (body)
+);
+CREATE UNIQUE INDEX uk_title USING BTREE ON post (title);
+CREATE INDEX idx_missing ON missing (id);`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	idxs := tbls[0].Indexes
+	if len(idxs) != 3 {
+		t.Fatalf("indexes = %+v", idxs)
+	}
+	if ix := idxs[0]; ix.Name != "idx_author" || ix.Unique || ix.Method != "btree" ||
+		strings.Join(ix.ColumnNames, ",") != "author_id,title(20)" {
+		t.Errorf("idx_author = %+v", ix)
+	}
+	if ix := idxs[1]; ix.Name != "ft_body" || ix.Method != "fulltext" {
+		t.Errorf("ft_body = %+v", ix)
+	}
+	if ix := idxs[2]; ix.Name != "uk_title" || !ix.Unique || ix.Method != "btree" {
+		t.Errorf("uk_title = %+v", ix)
+	}
+}
+
+func Test_ParseDDL_compositeFK(t *testing.T) {
+	tbls, err := ParseDDL([]byte(`
+CREATE TABLE order_line (order_id int, line_no int, PRIMARY KEY (order_id, line_no));
+CREATE TABLE shipment (
+  id int PRIMARY KEY,
+  order_id int NOT NULL,
+  line_no int,
+  CONSTRAINT shipment_line_fk FOREIGN KEY (order_id, line_no) REFERENCES order_line (order_id, line_no)
+);
+CREATE TABLE line_note (
+  order_id int, line_no int, note text,
+  PRIMARY KEY (order_id, line_no),
+  FOREIGN KEY (order_id, line_no) REFERENCES order_line
+);
+`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	orderLine, _ := schema.FindTableByName(tbls, "order_line")
+	shipment, _ := schema.FindTableByName(tbls, "shipment")
+	lineNote, _ := schema.FindTableByName(tbls, "line_note")
+	fks := shipment.ForeingKeys
+	if len(fks) != 1 {
+		t.Fatalf("foreign keys = %d, want 1", len(fks))
+	}
+	fk := fks[0]
+	if got := fk.SourceColNames(); !reflect.DeepEqual(got, []string{"order_id", "line_no"}) {
+		t.Errorf("source columns = %v", got)
+	}
+	if fk.Columns[1].TargetColumn != orderLine.Columns[1] || fk.SourceColumn != shipment.Columns[1] {
+		t.Errorf("columns are not linked: %+v", fk.Columns[1])
+	}
+	if fk.IsOneToOne() || fk.TargetCardinality() != schema.ZeroOrOne {
+		t.Errorf("shipment_line_fk: one to one %v, target cardinality %v", fk.IsOneToOne(), fk.TargetCardinality())
+	}
+
+	note := lineNote.ForeingKeys
+	if len(note) != 1 || len(note[0].Columns) != 2 || !note[0].IsOneToOne() {
+		t.Errorf("line_note foreign keys = %+v", note)
+	}
+}
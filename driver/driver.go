@@ -0,0 +1,226 @@
+// Package driver loads table definitions from databases and schema files
+package driver
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+	"sync"
+
+	"github.com/maocatooo/planter/schema"
+	"github.com/pkg/errors"
+)
+
+// Planter loads table definitions from a database or a schema file
+type Planter interface {
+	OpenDB(connStr string) error
+	// LoadTableDef load the tables, stops at the first query failing because ctx
+	// is canceled or past its deadline
+	LoadTableDef(ctx context.Context) ([]*schema.Table, error)
+}
+
+// Queryer database/sql compatible query interface
+type Queryer interface {
+	ExecContext(context.Context, string, ...interface{}) (sql.Result, error)
+	QueryContext(context.Context, string, ...interface{}) (*sql.Rows, error)
+	QueryRowContext(context.Context, string, ...interface{}) *sql.Row
+}
+
+// Options driver specific settings
+type Options struct {
+	// Schema PostgreSQL schema name, Default public
+	Schema string
+	// Filter keep only the tables it returns true for, the kept tables are then
+	// queried one by one instead of loading the whole catalog at once
+	Filter func(table string) bool
+	// Concurrency number of connections used to query filtered tables, Default 1
+	Concurrency int
+}
+
+// New create the Planter of the named driver
+func New(name string, opts Options) (Planter, error) {
+	switch name {
+	case "mysql":
+		return NewMysql(opts), nil
+	case "postgres":
+		if opts.Schema == "" {
+			opts.Schema = "public"
+		}
+		return NewPostgres(opts), nil
+	case "sqlite":
+		return NewSqlite(opts), nil
+	case "ddl":
+		return NewDDL(), nil
+	case "snapshot":
+		return NewSnapshot(), nil
+	}
+	return nil, errors.Errorf("unknown driver %s", name)
+}
+
+// parallel run fn(ctx, i) for i in [0, n) on up to workers goroutines, the
+// first error cancels the calls still running and is returned. When ctx ends
+// before every call started, fn is called once more with the first index left
+// so that the error names what was being loaded, fn must fail fast on a done
+// ctx like database/sql queries do
+func parallel(ctx context.Context, workers, n int, fn func(ctx context.Context, i int) error) error {
+	if workers < 1 {
+		workers = 1
+	}
+	if workers > n {
+		workers = n
+	}
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	var (
+		wg       sync.WaitGroup
+		once     sync.Once
+		firstErr error
+		next     = make(chan int)
+	)
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range next {
+				if err := fn(ctx, i); err != nil {
+					once.Do(func() {
+						firstErr = err
+						cancel()
+					})
+				}
+			}
+		}()
+	}
+	i := 0
+feed:
+	for ; i < n; i++ {
+		select {
+		case next <- i:
+		case <-ctx.Done():
+			break feed
+		}
+	}
+	close(next)
+	wg.Wait()
+	if firstErr != nil {
+		return firstErr
+	}
+	if err := ctx.Err(); err != nil {
+		if i < n {
+			if ferr := fn(ctx, i); ferr != nil {
+				return ferr
+			}
+		}
+		return err
+	}
+	return nil
+}
+
+// tableSetNames names listed by tableSet
+const tableSetNames = 5
+
+// tableSet database and tables covered by a bulk catalog query, named in its
+// errors, e.g. shop (42 tables: cart, order, product, review, user, ...)
+func tableSet(db string, tbls []*schema.Table) string {
+	names := make([]string, 0, tableSetNames+1)
+	for i, tbl := range tbls {
+		if i == tableSetNames {
+			names = append(names, "...")
+			break
+		}
+		names = append(names, tbl.Name)
+	}
+	return fmt.Sprintf("%s (%d tables: %s)", db, len(tbls), strings.Join(names, ", "))
+}
+
+// openDB open a pool of at most concurrency connections
+func openDB(driverName, connStr string, concurrency int) (*sql.DB, error) {
+	conn, err := sql.Open(driverName, connStr)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to connect to database")
+	}
+	if concurrency > 1 {
+		conn.SetMaxOpenConns(concurrency)
+	}
+	return conn, nil
+}
+
+func stripCommentSuffix(s string) string {
+	if tok := strings.SplitN(s, "\t", 2); len(tok) == 2 {
+		return tok[0]
+	}
+	return s
+}
+
+// scanUniqueKeyDefs scan (table, constraint, column) rows ordered by table,
+// constraint and column position, grouped by table name
+func scanUniqueKeyDefs(ukDefs *sql.Rows) (map[string][]*schema.UniqueKey, error) {
+	uks := map[string][]*schema.UniqueKey{}
+	for ukDefs.Next() {
+		var table, name, col string
+		if err := ukDefs.Scan(&table, &name, &col); err != nil {
+			return nil, err
+		}
+		keys := uks[table]
+		if len(keys) == 0 || keys[len(keys)-1].ConstraintName != name {
+			keys = append(keys, &schema.UniqueKey{ConstraintName: name})
+		}
+		uk := keys[len(keys)-1]
+		uk.ColumnNames = append(uk.ColumnNames, col)
+		uks[table] = keys
+	}
+	return uks, ukDefs.Err()
+}
+
+// addUniqueKey resolve the columns of uk and add it to tbl, a column alone in
+// a unique key is marked unique
+func addUniqueKey(tbl *schema.Table, uk *schema.UniqueKey) error {
+	uk.Columns = nil
+	for _, name := range uk.ColumnNames {
+		var col *schema.Column
+		for _, c := range tbl.Columns {
+			if c.Name == name {
+				col = c
+				break
+			}
+		}
+		if col == nil {
+			return errors.Errorf("%s: %s.%s not found", uk.ConstraintName, tbl.Name, name)
+		}
+		uk.Columns = append(uk.Columns, col)
+	}
+	if len(uk.Columns) == 1 {
+		uk.Columns[0].IsUnique = true
+	}
+	tbl.UniqueKeys = append(tbl.UniqueKeys, uk)
+	return nil
+}
+
+// scanIndexDefs scan (table, index, unique, method, predicate, column) rows
+// ordered by table, index and key position, grouped by table name
+func scanIndexDefs(idxDefs *sql.Rows) (map[string][]*schema.Index, error) {
+	idxs := map[string][]*schema.Index{}
+	for idxDefs.Next() {
+		var (
+			table string
+			ix    schema.Index
+			col   sql.NullString
+		)
+		if err := idxDefs.Scan(&table, &ix.Name, &ix.Unique, &ix.Method, &ix.Predicate, &col); err != nil {
+			return nil, err
+		}
+		if !col.Valid {
+			col.String = "(expression)"
+		}
+		keys := idxs[table]
+		if len(keys) == 0 || keys[len(keys)-1].Name != ix.Name {
+			keys = append(keys, &ix)
+		}
+		last := keys[len(keys)-1]
+		last.ColumnNames = append(last.ColumnNames, col.String)
+		idxs[table] = keys
+	}
+	return idxs, idxDefs.Err()
+}
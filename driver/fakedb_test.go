@@ -0,0 +1,302 @@
+package driver
+
+import (
+	"context"
+	"database/sql"
+	sqldriver "database/sql/driver"
+	"fmt"
+	"io"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// fakeDB database/sql driver answering catalog queries from memory, every
+// query waits latency to model the round trip to a remote server
+type fakeDB struct {
+	latency time.Duration
+	queries int64
+	// inflight queries running, maxInflight the most seen at once
+	inflight    int64
+	maxInflight int64
+	handle      func(query string, args []sqldriver.NamedValue) ([]string, [][]sqldriver.Value, error)
+}
+
+var (
+	fakeDBs      sync.Map
+	fakeDBSeq    int64
+	registerFake sync.Once
+)
+
+type fakeDriver struct{}
+
+func (fakeDriver) Open(name string) (sqldriver.Conn, error) {
+	db, ok := fakeDBs.Load(name)
+	if !ok {
+		return nil, errors.Errorf("unknown fake db %s", name)
+	}
+	return &fakeConn{db: db.(*fakeDB)}, nil
+}
+
+// open register db and return a *sql.DB connected to it
+func (db *fakeDB) open() *sql.DB {
+	registerFake.Do(func() { sql.Register("planterfake", fakeDriver{}) })
+	name := fmt.Sprintf("fake%d", atomic.AddInt64(&fakeDBSeq, 1))
+	fakeDBs.Store(name, db)
+	conn, err := sql.Open("planterfake", name)
+	if err != nil {
+		panic(err)
+	}
+	return conn
+}
+
+type fakeConn struct {
+	db *fakeDB
+}
+
+func (c *fakeConn) Prepare(query string) (sqldriver.Stmt, error) {
+	return nil, errors.New("prepare is not supported")
+}
+
+func (c *fakeConn) Close() error { return nil }
+
+func (c *fakeConn) Begin() (sqldriver.Tx, error) {
+	return nil, errors.New("transactions are not supported")
+}
+
+func (c *fakeConn) QueryContext(ctx context.Context, query string, args []sqldriver.NamedValue) (sqldriver.Rows, error) {
+	atomic.AddInt64(&c.db.queries, 1)
+	n := atomic.AddInt64(&c.db.inflight, 1)
+	defer atomic.AddInt64(&c.db.inflight, -1)
+	for {
+		max := atomic.LoadInt64(&c.db.maxInflight)
+		if n <= max || atomic.CompareAndSwapInt64(&c.db.maxInflight, max, n) {
+			break
+		}
+	}
+	if c.db.latency > 0 {
+		select {
+		case <-time.After(c.db.latency):
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+	}
+	cols, vals, err := c.db.handle(query, args)
+	if err != nil {
+		return nil, err
+	}
+	return &fakeRows{cols: cols, vals: vals}, nil
+}
+
+type fakeRows struct {
+	cols []string
+	vals [][]sqldriver.Value
+}
+
+func (r *fakeRows) Columns() []string { return r.cols }
+
+func (r *fakeRows) Close() error { return nil }
+
+func (r *fakeRows) Next(dest []sqldriver.Value) error {
+	if len(r.vals) == 0 {
+		return io.EOF
+	}
+	copy(dest, r.vals[0])
+	r.vals = r.vals[1:]
+	return nil
+}
+
+// fakeTable table of a generated catalog, every table has an id primary key,
+// a unique name and an indexed parent_id referencing the previous table
+type fakeTable struct {
+	name   string
+	parent string
+}
+
+func fakeTables(n int) []fakeTable {
+	tbls := make([]fakeTable, n)
+	for i := range tbls {
+		tbls[i].name = fmt.Sprintf("t%04d", i)
+		if i > 0 {
+			tbls[i].parent = tbls[i-1].name
+		}
+	}
+	return tbls
+}
+
+func argString(args []sqldriver.NamedValue, i int) string {
+	s, _ := args[i].Value.(string)
+	return s
+}
+
+// fakeMySQL answer the catalog queries of the mysql driver
+func fakeMySQL(n int, latency time.Duration) *fakeDB {
+	tbls := fakeTables(n)
+	columns := func(t fakeTable) [][]sqldriver.Value {
+		return [][]sqldriver.Value{
+			{t.name, int64(1), "id", "", "int", "PRI", "NO", nil, "auto_increment"},
+			{t.name, int64(2), "name", "name of " + t.name, "varchar(50)", "", "NO", "", ""},
+			{t.name, int64(3), "parent_id", nil, "int", "MUL", "YES", nil, ""},
+		}
+	}
+	uks := func(table string) [][]sqldriver.Value {
+		var rows [][]sqldriver.Value
+		for _, t := range tbls {
+			if table == "" || t.name == table {
+				rows = append(rows, []sqldriver.Value{t.name, "name", "name"})
+			}
+		}
+		return rows
+	}
+	idxs := func(table string) [][]sqldriver.Value {
+		var rows [][]sqldriver.Value
+		for _, t := range tbls {
+			if table == "" || t.name == table {
+				rows = append(rows,
+					[]sqldriver.Value{t.name, "fk_" + t.name, "0", "btree", "", "parent_id"},
+					[]sqldriver.Value{t.name, "name", "1", "btree", "", "name"})
+			}
+		}
+		return rows
+	}
+	fks := func(referenced string) [][]sqldriver.Value {
+		var rows [][]sqldriver.Value
+		for _, t := range tbls {
+			if t.parent != "" && (referenced == "" || t.parent == referenced) {
+				rows = append(rows, []sqldriver.Value{t.parent, "id", t.name, "parent_id", "fk_" + t.name})
+			}
+		}
+		return rows
+	}
+	colNames := []string{"TABLE_NAME", "ORDINAL_POSITION", "COLUMN_NAME", "COLUMN_COMMENT", "COLUMN_TYPE", "COLUMN_KEY", "IS_NULLABLE", "COLUMN_DEFAULT", "EXTRA"}
+	ukNames := []string{"TABLE_NAME", "CONSTRAINT_NAME", "COLUMN_NAME"}
+	idxNames := []string{"TABLE_NAME", "INDEX_NAME", "NON_UNIQUE = 0", "LOWER(INDEX_TYPE)", "", "COLUMN_NAME"}
+	fkNames := []string{"REFERENCED_TABLE_NAME", "REFERENCED_COLUMN_NAME", "TABLE_NAME", "COLUMN_NAME", "CONSTRAINT_NAME"}
+	return &fakeDB{
+		latency: latency,
+		handle: func(query string, args []sqldriver.NamedValue) ([]string, [][]sqldriver.Value, error) {
+			switch query {
+			case _MySQLCurrentDataBaseSQL:
+				return []string{"DATABASE()"}, [][]sqldriver.Value{{"shop"}}, nil
+			case _MySQLTableDefSQL:
+				var rows [][]sqldriver.Value
+				for _, t := range tbls {
+					rows = append(rows, []sqldriver.Value{t.name, ""})
+				}
+				return []string{"TABLE_NAME", "TABLE_COMMENT"}, rows, nil
+			case _MySQLColumDefSQL:
+				for _, t := range tbls {
+					if t.name == argString(args, 1) {
+						return colNames, columns(t), nil
+					}
+				}
+				return colNames, nil, nil
+			case _MySQLBulkColumDefSQL:
+				var rows [][]sqldriver.Value
+				for _, t := range tbls {
+					rows = append(rows, columns(t)...)
+				}
+				return colNames, rows, nil
+			case _MySQLUniqueKeyDefSQL:
+				return ukNames, uks(argString(args, 1)), nil
+			case _MySQLBulkUniqueKeyDefSQL:
+				return ukNames, uks(""), nil
+			case _MySQLIndexDefSQL:
+				return idxNames, idxs(argString(args, 1)), nil
+			case _MySQLBulkIndexDefSQL:
+				return idxNames, idxs(""), nil
+			case _MySQLFKDefSQL:
+				return fkNames, fks(argString(args, 1)), nil
+			case _MySQLBulkFKDefSQL:
+				return fkNames, fks(""), nil
+			}
+			return nil, nil, errors.New("unexpected query")
+		},
+	}
+}
+
+// fakePostgres answer the catalog queries of the postgres driver
+func fakePostgres(n int, latency time.Duration) *fakeDB {
+	tbls := fakeTables(n)
+	columns := func(t fakeTable) [][]sqldriver.Value {
+		return [][]sqldriver.Value{
+			{t.name, int64(1), "id", nil, "integer", true, true, "serial"},
+			{t.name, int64(2), "name", "name of " + t.name, "character varying(50)", true, false, "character varying(50)"},
+			{t.name, int64(3), "parent_id", nil, "integer", false, false, "integer"},
+		}
+	}
+	uks := func(table string) [][]sqldriver.Value {
+		var rows [][]sqldriver.Value
+		for _, t := range tbls {
+			if table == "" || t.name == table {
+				rows = append(rows, []sqldriver.Value{t.name, t.name + "_name_key", "name"})
+			}
+		}
+		return rows
+	}
+	idxs := func(table string) [][]sqldriver.Value {
+		var rows [][]sqldriver.Value
+		for _, t := range tbls {
+			if table == "" || t.name == table {
+				rows = append(rows,
+					[]sqldriver.Value{t.name, t.name + "_name_key", true, "btree", "", "name"},
+					[]sqldriver.Value{t.name, t.name + "_parent_id_idx", false, "btree", "(parent_id IS NOT NULL)", "parent_id"})
+			}
+		}
+		return rows
+	}
+	fks := func(source string) [][]sqldriver.Value {
+		var rows [][]sqldriver.Value
+		for _, t := range tbls {
+			if t.parent != "" && (source == "" || t.name == source) {
+				rows = append(rows, []sqldriver.Value{t.name, "parent_id", t.parent, "id", t.name + "_parent_id_fkey", true, false})
+			}
+		}
+		return rows
+	}
+	colNames := []string{"table_name", "field_ordinal", "column_name", "description", "data_type", "not_null", "is_primary_key", "data_type"}
+	ukNames := []string{"table_name", "constraint_name", "column_name"}
+	idxNames := []string{"table_name", "index_name", "is_unique", "method", "predicate", "column_name"}
+	fkNames := []string{"child_table", "child_column", "parent_table", "parent_column", "conname", "is_parent_pk", "is_child_pk"}
+	return &fakeDB{
+		latency: latency,
+		handle: func(query string, args []sqldriver.NamedValue) ([]string, [][]sqldriver.Value, error) {
+			switch query {
+			case _PGSQLTableDefSQL:
+				var rows [][]sqldriver.Value
+				for _, t := range tbls {
+					rows = append(rows, []sqldriver.Value{t.name, nil})
+				}
+				return []string{"table_name", "description"}, rows, nil
+			case _PGSQLcolumDefSQL:
+				for _, t := range tbls {
+					if t.name == argString(args, 1) {
+						return colNames, columns(t), nil
+					}
+				}
+				return colNames, nil, nil
+			case _PGSQLBulkColumDefSQL:
+				var rows [][]sqldriver.Value
+				for _, t := range tbls {
+					rows = append(rows, columns(t)...)
+				}
+				return colNames, rows, nil
+			case _PGSQLUniqueKeyDefSQL:
+				return ukNames, uks(argString(args, 1)), nil
+			case _PGSQLBulkUniqueKeyDefSQL:
+				return ukNames, uks(""), nil
+			case _PGSQLIndexDefSQL:
+				return idxNames, idxs(argString(args, 1)), nil
+			case _PGSQLBulkIndexDefSQL:
+				return idxNames, idxs(""), nil
+			case _PGSQLFKDefSQL:
+				return fkNames, fks(argString(args, 1)), nil
+			case _PGSQLBulkFKDefSQL:
+				return fkNames, fks(""), nil
+			}
+			return nil, nil, errors.New("unexpected query")
+		},
+	}
+}
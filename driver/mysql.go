@@ -0,0 +1,523 @@
+package driver
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+
+	mysqldriver "github.com/go-sql-driver/mysql"
+	"github.com/maocatooo/planter/schema"
+	"github.com/pkg/errors"
+)
+
+const _MySQLCurrentDataBaseSQL = `
+SELECT DATABASE();
+`
+
+const _MySQLTableDefSQL = `
+SELECT
+    TABLE_NAME,TABLE_COMMENT
+FROM
+    information_schema.TABLES a
+WHERE
+    a.table_schema = ?
+
+`
+
+const _MySQLColumDefSQL = `
+SELECT
+    a.TABLE_NAME, b.ORDINAL_POSITION, b.COLUMN_NAME, b.COLUMN_COMMENT, b.COLUMN_TYPE, b.COLUMN_KEY,b.IS_NULLABLE, b.COLUMN_DEFAULT, b.EXTRA
+FROM
+    information_schema.TABLES a
+        LEFT JOIN information_schema.COLUMNS b ON a.table_name = b.TABLE_NAME and a.TABLE_SCHEMA = b.TABLE_SCHEMA
+WHERE
+        a.TABLE_SCHEMA = ? AND a.table_name = ? ORDER BY b.ORDINAL_POSITION
+`
+
+// _MySQLBulkColumDefSQL columns of every table of the database in one query
+const _MySQLBulkColumDefSQL = `
+SELECT
+    b.TABLE_NAME, b.ORDINAL_POSITION, b.COLUMN_NAME, b.COLUMN_COMMENT, b.COLUMN_TYPE, b.COLUMN_KEY,b.IS_NULLABLE, b.COLUMN_DEFAULT, b.EXTRA
+FROM
+    information_schema.COLUMNS b
+WHERE
+        b.TABLE_SCHEMA = ? ORDER BY b.TABLE_NAME, b.ORDINAL_POSITION
+`
+
+const _MySQLFKDefSQL = `
+SELECT REFERENCED_TABLE_NAME, REFERENCED_COLUMN_NAME, TABLE_NAME, COLUMN_NAME, CONSTRAINT_NAME
+FROM INFORMATION_SCHEMA.KEY_COLUMN_USAGE
+WHERE CONSTRAINT_SCHEMA = ?  AND REFERENCED_TABLE_NAME = ?
+ORDER BY TABLE_NAME, CONSTRAINT_NAME, ORDINAL_POSITION
+`
+
+// _MySQLBulkFKDefSQL foreign keys of every table of the database in one query
+const _MySQLBulkFKDefSQL = `
+SELECT REFERENCED_TABLE_NAME, REFERENCED_COLUMN_NAME, TABLE_NAME, COLUMN_NAME, CONSTRAINT_NAME
+FROM INFORMATION_SCHEMA.KEY_COLUMN_USAGE
+WHERE CONSTRAINT_SCHEMA = ?  AND REFERENCED_TABLE_NAME IS NOT NULL
+ORDER BY REFERENCED_TABLE_NAME, TABLE_NAME, CONSTRAINT_NAME, ORDINAL_POSITION
+`
+
+const _MySQLUniqueKeyDefSelect = `
+SELECT tc.TABLE_NAME, tc.CONSTRAINT_NAME, k.COLUMN_NAME
+FROM information_schema.TABLE_CONSTRAINTS tc
+JOIN information_schema.KEY_COLUMN_USAGE k ON k.CONSTRAINT_SCHEMA = tc.CONSTRAINT_SCHEMA
+    AND k.TABLE_NAME = tc.TABLE_NAME AND k.CONSTRAINT_NAME = tc.CONSTRAINT_NAME
+WHERE tc.CONSTRAINT_SCHEMA = ? AND tc.CONSTRAINT_TYPE = 'UNIQUE'
+`
+
+const _MySQLUniqueKeyDefSQL = _MySQLUniqueKeyDefSelect + `AND tc.TABLE_NAME = ?
+ORDER BY tc.CONSTRAINT_NAME, k.ORDINAL_POSITION
+`
+
+// _MySQLBulkUniqueKeyDefSQL unique keys of every table of the database in one query
+const _MySQLBulkUniqueKeyDefSQL = _MySQLUniqueKeyDefSelect + `ORDER BY tc.TABLE_NAME, tc.CONSTRAINT_NAME, k.ORDINAL_POSITION
+`
+
+const _MySQLIndexDefSelect = `
+SELECT
+    TABLE_NAME, INDEX_NAME, NON_UNIQUE = 0, LOWER(INDEX_TYPE), '',
+    CASE WHEN SUB_PART IS NULL THEN COLUMN_NAME ELSE CONCAT(COLUMN_NAME, '(', SUB_PART, ')') END
+FROM information_schema.STATISTICS
+WHERE TABLE_SCHEMA = ? AND INDEX_NAME <> 'PRIMARY'
+`
+
+const _MySQLIndexDefSQL = _MySQLIndexDefSelect + `AND TABLE_NAME = ?
+ORDER BY INDEX_NAME, SEQ_IN_INDEX
+`
+
+// _MySQLBulkIndexDefSQL indexes of every table of the database in one query
+const _MySQLBulkIndexDefSQL = _MySQLIndexDefSelect + `ORDER BY TABLE_NAME, INDEX_NAME, SEQ_IN_INDEX
+`
+
+type mysql struct {
+	db               Queryer
+	filter           func(string) bool
+	concurrency      int
+	_currentDataBase string
+}
+
+func NewMysql(opts Options) Planter {
+	return &mysql{
+		filter:      opts.Filter,
+		concurrency: opts.Concurrency,
+	}
+}
+
+func (m *mysql) OpenDB(connStr string) error {
+	conn, err := openDB("mysql", connStr, m.concurrency)
+	if err != nil {
+		return err
+	}
+	m.db = conn
+	return nil
+}
+
+// _MySQLMaxExecutionTime error of a query stopped by the MAX_EXECUTION_TIME hint
+const _MySQLMaxExecutionTime = 3024
+
+// CheckInclusion sample fk, the remaining time of ctx is also passed to the
+// server as a MAX_EXECUTION_TIME hint since canceling only drops the connection
+func (m *mysql) CheckInclusion(ctx context.Context, fk *schema.ForeignKey, limit int) (int, int, error) {
+	hint := ""
+	if deadline, ok := ctx.Deadline(); ok {
+		left := time.Until(deadline)
+		if left <= 0 {
+			return 0, 0, context.DeadlineExceeded
+		}
+		hint = maxExecutionTimeHint(left)
+	}
+	q := quoteIdent("`")
+	sampled, found, err := checkInclusion(ctx, m.db, inclusionSQL(fk, hint, "?", q, q), limit)
+	var merr *mysqldriver.MySQLError
+	if errors.As(err, &merr) && merr.Number == _MySQLMaxExecutionTime {
+		return 0, 0, context.DeadlineExceeded
+	}
+	return sampled, found, err
+}
+
+// maxExecutionTimeHint optimizer hint limiting a query to left, at least 1ms
+// since MAX_EXECUTION_TIME(0) is no limit
+func maxExecutionTimeHint(left time.Duration) string {
+	ms := left.Milliseconds()
+	if ms < 1 {
+		ms = 1
+	}
+	return fmt.Sprintf("/*+ MAX_EXECUTION_TIME(%d) */ ", ms)
+}
+
+func (m *mysql) loadCurrentDataBase(ctx context.Context) (string, error) {
+	if m._currentDataBase != `` {
+		return m._currentDataBase, nil
+	}
+	dbName, err := m.db.QueryContext(ctx, _MySQLCurrentDataBaseSQL)
+	if err != nil {
+		return "", errors.Wrap(err, "failed to load dbName def")
+	}
+	defer dbName.Close()
+	for dbName.Next() {
+		var name sql.NullString
+		if err := dbName.Scan(&name); err != nil {
+			return "", errors.Wrap(err, "failed to scan")
+		}
+		if !name.Valid {
+			return "", errors.New("no database selected in connection string")
+		}
+		m._currentDataBase = name.String
+		return m._currentDataBase, nil
+	}
+	if err := dbName.Err(); err != nil {
+		return "", errors.Wrap(err, "failed to load current database")
+	}
+	return "", errors.New("failed to load current database")
+}
+
+// scanColumnDefs scan column rows, grouped by table name
+func (m *mysql) scanColumnDefs(colDefs *sql.Rows) (map[string][]*schema.Column, error) {
+	cols := map[string][]*schema.Column{}
+	for colDefs.Next() {
+		var (
+			table string
+			c     mySQLColumn
+		)
+		err := colDefs.Scan(
+			&table,
+			&c.FieldOrdinal,
+			&c.Name,
+			&c.Comment,
+			&c.DataType,
+			&c.KeyType,
+			&c.Nullable,
+			&c.Default,
+			&c.Extra,
+		)
+		if err != nil {
+			return nil, err
+		}
+		c.format()
+		c.Comment.String = stripCommentSuffix(c.Comment.String)
+		cols[table] = append(cols[table], c.toColumn())
+	}
+	return cols, colDefs.Err()
+}
+
+// LoadColumnDef load Postgres column definition
+func (m *mysql) loadColumnDef(ctx context.Context, table string) ([]*schema.Column, error) {
+	dbName, err := m.loadCurrentDataBase(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	colDefs, err := m.db.QueryContext(ctx, _MySQLColumDefSQL, dbName, table)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to load column def of %s", table)
+	}
+	defer colDefs.Close()
+	cols, err := m.scanColumnDefs(colDefs)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to load column def of %s", table)
+	}
+	return cols[table], nil
+}
+
+// scanForeignKeyDefs scan fk rows, grouped by referenced table name
+func (m *mysql) scanForeignKeyDefs(fkDefs *sql.Rows) (map[string][]*schema.ForeignKey, error) {
+	fks := map[string][]*schema.ForeignKey{}
+	for fkDefs.Next() {
+		var fk schema.ForeignKey
+		err := fkDefs.Scan(
+			&fk.TargetTableName,
+			&fk.TargetColName,
+			&fk.SourceTableName,
+			&fk.SourceColName,
+			&fk.ConstraintName,
+		)
+		if err != nil {
+			return nil, err
+		}
+		fks[fk.TargetTableName] = append(fks[fk.TargetTableName], &fk)
+	}
+	return fks, fkDefs.Err()
+}
+
+// linkForeignKeys resolve the tables and columns of the fks referencing tbl
+// and group the column pairs by constraint, fks from tables dropped by the
+// filter are skipped
+func (m *mysql) linkForeignKeys(tbls []*schema.Table, tbl *schema.Table, fks []*schema.ForeignKey) ([]*schema.ForeignKey, error) {
+	var linked []*schema.ForeignKey
+	for _, fk := range fks {
+		fk.TargetTable = tbl
+		targetCol, found := schema.FindColumnByName(tbls, fk.TargetTableName, fk.TargetColName)
+		if !found {
+			return nil, errors.Errorf("%s: %s.%s not found", fk.ConstraintName, fk.TargetTableName, fk.TargetColName)
+		}
+		fk.TargetColumn = targetCol
+		fk.IsTargetColPrimaryKey = targetCol.IsPrimaryKey
+
+		sourceTbl, found := schema.FindTableByName(tbls, fk.SourceTableName)
+		if !found {
+			if m.filter != nil && !m.filter(fk.SourceTableName) {
+				continue
+			}
+			return nil, errors.Errorf("%s: %s not found", fk.ConstraintName, fk.SourceTableName)
+		}
+		fk.SourceTable = sourceTbl
+		sourceCol, found := schema.FindColumnByName(tbls, fk.SourceTableName, fk.SourceColName)
+		if !found {
+			return nil, errors.Errorf("%s: %s.%s not found", fk.ConstraintName, fk.SourceTableName, fk.SourceColName)
+		}
+		sourceCol.IsForeignKey = true
+		fk.SourceColumn = sourceCol
+		fk.IsSourceColPrimaryKey = sourceCol.IsPrimaryKey
+		linked = append(linked, fk)
+	}
+	return schema.GroupForeignKeys(linked), nil
+}
+
+// LoadForeignKeyDef load Postgres fk definition, linked by linkForeignKeys once
+// all columns are loaded
+func (m *mysql) loadForeignKeyDef(ctx context.Context, tbl *schema.Table) ([]*schema.ForeignKey, error) {
+	dbName, err := m.loadCurrentDataBase(ctx)
+	if err != nil {
+		return nil, err
+	}
+	fkDefs, err := m.db.QueryContext(ctx, _MySQLFKDefSQL, dbName, tbl.Name)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to load fk def of %s", tbl.Name)
+	}
+	defer fkDefs.Close()
+	fks, err := m.scanForeignKeyDefs(fkDefs)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to load fk def of %s", tbl.Name)
+	}
+	return fks[tbl.Name], nil
+}
+
+// loadUniqueKeyDef load the unique keys of tbl, columns must be loaded
+func (m *mysql) loadUniqueKeyDef(ctx context.Context, tbl *schema.Table) error {
+	dbName, err := m.loadCurrentDataBase(ctx)
+	if err != nil {
+		return err
+	}
+	ukDefs, err := m.db.QueryContext(ctx, _MySQLUniqueKeyDefSQL, dbName, tbl.Name)
+	if err != nil {
+		return errors.Wrapf(err, "failed to load unique key def of %s", tbl.Name)
+	}
+	defer ukDefs.Close()
+	uks, err := scanUniqueKeyDefs(ukDefs)
+	if err != nil {
+		return errors.Wrapf(err, "failed to load unique key def of %s", tbl.Name)
+	}
+	for _, uk := range uks[tbl.Name] {
+		if err := addUniqueKey(tbl, uk); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// loadIndexDef load the indexes of tbl
+func (m *mysql) loadIndexDef(ctx context.Context, tbl *schema.Table) error {
+	dbName, err := m.loadCurrentDataBase(ctx)
+	if err != nil {
+		return err
+	}
+	idxDefs, err := m.db.QueryContext(ctx, _MySQLIndexDefSQL, dbName, tbl.Name)
+	if err != nil {
+		return errors.Wrapf(err, "failed to load index def of %s", tbl.Name)
+	}
+	defer idxDefs.Close()
+	idxs, err := scanIndexDefs(idxDefs)
+	if err != nil {
+		return errors.Wrapf(err, "failed to load index def of %s", tbl.Name)
+	}
+	tbl.Indexes = idxs[tbl.Name]
+	return nil
+}
+
+// loadTableDefs load the tables kept by the filter, columns and fks not loaded yet
+func (m *mysql) loadTableDefs(ctx context.Context, dbName string) ([]*schema.Table, error) {
+	tbDefs, err := m.db.QueryContext(ctx, _MySQLTableDefSQL, dbName)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to load table def")
+	}
+	defer tbDefs.Close()
+	var tbls []*schema.Table
+	for tbDefs.Next() {
+		t := &schema.Table{}
+		err := tbDefs.Scan(
+			&t.Name,
+			&t.Comment,
+		)
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to scan table def")
+		}
+		if m.filter != nil && !m.filter(t.Name) {
+			continue
+		}
+		tbls = append(tbls, t)
+	}
+	if err := tbDefs.Err(); err != nil {
+		return nil, errors.Wrap(err, "failed to load table def")
+	}
+	return tbls, nil
+}
+
+// loadBulk load the columns, unique keys, indexes and fks of all tables with one query each
+func (m *mysql) loadBulk(ctx context.Context, dbName string, tbls []*schema.Table) error {
+	colDefs, err := m.db.QueryContext(ctx, _MySQLBulkColumDefSQL, dbName)
+	if err != nil {
+		return errors.Wrapf(err, "failed to load column defs of %s", tableSet(dbName, tbls))
+	}
+	defer colDefs.Close()
+	cols, err := m.scanColumnDefs(colDefs)
+	if err != nil {
+		return errors.Wrapf(err, "failed to load column defs of %s", tableSet(dbName, tbls))
+	}
+	colDefs.Close()
+	for _, tbl := range tbls {
+		tbl.Columns = cols[tbl.Name]
+	}
+
+	ukDefs, err := m.db.QueryContext(ctx, _MySQLBulkUniqueKeyDefSQL, dbName)
+	if err != nil {
+		return errors.Wrapf(err, "failed to load unique key defs of %s", tableSet(dbName, tbls))
+	}
+	defer ukDefs.Close()
+	uks, err := scanUniqueKeyDefs(ukDefs)
+	if err != nil {
+		return errors.Wrapf(err, "failed to load unique key defs of %s", tableSet(dbName, tbls))
+	}
+	ukDefs.Close()
+	for _, tbl := range tbls {
+		for _, uk := range uks[tbl.Name] {
+			if err := addUniqueKey(tbl, uk); err != nil {
+				return err
+			}
+		}
+	}
+
+	idxDefs, err := m.db.QueryContext(ctx, _MySQLBulkIndexDefSQL, dbName)
+	if err != nil {
+		return errors.Wrapf(err, "failed to load index defs of %s", tableSet(dbName, tbls))
+	}
+	defer idxDefs.Close()
+	idxs, err := scanIndexDefs(idxDefs)
+	if err != nil {
+		return errors.Wrapf(err, "failed to load index defs of %s", tableSet(dbName, tbls))
+	}
+	idxDefs.Close()
+	for _, tbl := range tbls {
+		tbl.Indexes = idxs[tbl.Name]
+	}
+
+	fkDefs, err := m.db.QueryContext(ctx, _MySQLBulkFKDefSQL, dbName)
+	if err != nil {
+		return errors.Wrapf(err, "failed to load fk defs of %s", tableSet(dbName, tbls))
+	}
+	defer fkDefs.Close()
+	fks, err := m.scanForeignKeyDefs(fkDefs)
+	if err != nil {
+		return errors.Wrapf(err, "failed to load fk defs of %s", tableSet(dbName, tbls))
+	}
+	for _, tbl := range tbls {
+		if tbl.ForeingKeys, err = m.linkForeignKeys(tbls, tbl, fks[tbl.Name]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// LoadTableDef load Mysql table definition, the whole database is loaded with
+// bulk catalog queries, filtered tables are queried one by one on up to
+// Options.Concurrency connections
+func (m *mysql) LoadTableDef(ctx context.Context) ([]*schema.Table, error) {
+	dbName, err := m.loadCurrentDataBase(ctx)
+	if err != nil {
+		return nil, err
+	}
+	tbls, err := m.loadTableDefs(ctx, dbName)
+	if err != nil {
+		return nil, err
+	}
+	if m.filter == nil {
+		if err := m.loadBulk(ctx, dbName, tbls); err != nil {
+			return nil, err
+		}
+		return tbls, nil
+	}
+
+	fks := make([][]*schema.ForeignKey, len(tbls))
+	err = parallel(ctx, m.concurrency, len(tbls), func(ctx context.Context, i int) error {
+		cols, err := m.loadColumnDef(ctx, tbls[i].Name)
+		if err != nil {
+			return err
+		}
+		tbls[i].Columns = cols
+		if err := m.loadUniqueKeyDef(ctx, tbls[i]); err != nil {
+			return err
+		}
+		if err := m.loadIndexDef(ctx, tbls[i]); err != nil {
+			return err
+		}
+		fks[i], err = m.loadForeignKeyDef(ctx, tbls[i])
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	for i, tbl := range tbls {
+		if tbl.ForeingKeys, err = m.linkForeignKeys(tbls, tbl, fks[i]); err != nil {
+			return nil, err
+		}
+	}
+	return tbls, nil
+}
+
+type mySQLColumn struct {
+	FieldOrdinal int
+	Name         string
+	Comment      sql.NullString
+	DataType     string
+
+	KeyType      string
+	Nullable     string
+	Default      sql.NullString
+	Extra        string
+	NotNull      bool
+	IsPrimaryKey bool
+	IsForeignKey bool
+}
+
+func (m *mySQLColumn) toColumn() *schema.Column {
+	return &schema.Column{
+		FieldOrdinal: m.FieldOrdinal,
+		Name:         m.Name,
+		Comment:      m.Comment,
+		DataType:     m.DataType,
+		DDLType:      m.DataType,
+		NotNull:      m.NotNull,
+		Default:      m.Default,
+		Extra:        m.Extra,
+		IsPrimaryKey: m.IsPrimaryKey,
+		IsForeignKey: m.IsForeignKey,
+	}
+}
+
+func (m *mySQLColumn) format() {
+	m.NotNull = m.Nullable == "NO"
+	// DEFAULT_GENERATED only flags expression defaults such as CURRENT_TIMESTAMP
+	var extra []string
+	for _, e := range strings.Fields(m.Extra) {
+		if e != "DEFAULT_GENERATED" {
+			extra = append(extra, e)
+		}
+	}
+	m.Extra = strings.Join(extra, " ")
+	if m.KeyType == "PRI" {
+		m.IsPrimaryKey = true
+	}
+}
@@ -0,0 +1,154 @@
+package driver
+
+import (
+	"context"
+	"database/sql"
+	sqldriver "database/sql/driver"
+	"os"
+	"testing"
+
+	"github.com/maocatooo/planter/schema"
+	"github.com/pkg/errors"
+)
+
+// fakeMySQLShop answer the bulk catalog queries with information_schema rows
+// as MySQL 8 reports them
+func fakeMySQLShop() *fakeDB {
+	cols := [][]sqldriver.Value{
+		{"product", int64(1), "product_id", "商品ID", "int", "PRI", "NO", nil, "auto_increment"},
+		{"product", int64(2), "product_name", "商品名称", "varchar(255)", "", "NO", nil, ""},
+		{"product", int64(3), "description", "商品描述", "text", "", "YES", nil, ""},
+		{"product", int64(4), "price", "商品价格", "decimal(10,2)", "", "NO", nil, ""},
+		{"product", int64(5), "stock_quantity", "库存数量", "int", "", "NO", nil, ""},
+		{"product", int64(6), "category", "商品类别 ", "varchar(50)", "", "YES", nil, ""},
+		{"product", int64(7), "created_at", "创建时间", "timestamp", "", "YES", "CURRENT_TIMESTAMP", "DEFAULT_GENERATED"},
+		{"product", int64(8), "updated_at", "修改时间", "timestamp", "", "YES", "CURRENT_TIMESTAMP", "DEFAULT_GENERATED on update CURRENT_TIMESTAMP"},
+		{"user", int64(1), "user_id", "用户ID", "int", "PRI", "NO", nil, "auto_increment"},
+		{"user", int64(2), "username", "用户名称", "varchar(50)", "", "NO", nil, ""},
+		{"user", int64(3), "email", "电子邮件", "varchar(100)", "UNI", "NO", nil, ""},
+		{"user", int64(4), "password_hash", "密码哈希", "char(60)", "", "NO", nil, ""},
+		{"user", int64(5), "first_name", "名字", "varchar(50)", "", "YES", nil, ""},
+		{"user", int64(6), "last_name", "姓氏", "varchar(50)", "", "YES", nil, ""},
+		{"user", int64(7), "created_at", "创建时间", "timestamp", "", "YES", "CURRENT_TIMESTAMP", "DEFAULT_GENERATED"},
+		{"user", int64(8), "updated_at", "更新时间", "timestamp", "", "YES", "CURRENT_TIMESTAMP", "DEFAULT_GENERATED on update CURRENT_TIMESTAMP"},
+		{"cart", int64(1), "cart_id", "购物车ID", "int", "PRI", "NO", nil, "auto_increment"},
+		{"cart", int64(2), "user_id", "用户ID", "int", "MUL", "NO", nil, ""},
+		{"cart", int64(3), "product_id", "商品ID", "int", "MUL", "NO", nil, ""},
+		{"cart", int64(4), "quantity", "商品数量", "int", "", "NO", nil, ""},
+		{"cart", int64(5), "added_at", "添加时间", "timestamp", "", "YES", "CURRENT_TIMESTAMP", "DEFAULT_GENERATED"},
+	}
+	uks := [][]sqldriver.Value{{"user", "email", "email"}}
+	idxs := [][]sqldriver.Value{
+		{"cart", "product_id", "0", "btree", "", "product_id"},
+		{"cart", "user_id", "0", "btree", "", "user_id"},
+		{"user", "email", "1", "btree", "", "email"},
+	}
+	fks := [][]sqldriver.Value{
+		{"product", "product_id", "cart", "product_id", "cart_ibfk_2"},
+		{"user", "user_id", "cart", "user_id", "cart_ibfk_1"},
+	}
+	return &fakeDB{
+		handle: func(query string, args []sqldriver.NamedValue) ([]string, [][]sqldriver.Value, error) {
+			switch query {
+			case _MySQLCurrentDataBaseSQL:
+				return []string{"DATABASE()"}, [][]sqldriver.Value{{"shop"}}, nil
+			case _MySQLTableDefSQL:
+				return []string{"TABLE_NAME", "TABLE_COMMENT"}, [][]sqldriver.Value{{"cart", "购物车"}, {"product", "商品"}, {"user", "用户"}}, nil
+			case _MySQLBulkColumDefSQL:
+				return []string{"TABLE_NAME", "ORDINAL_POSITION", "COLUMN_NAME", "COLUMN_COMMENT", "COLUMN_TYPE", "COLUMN_KEY", "IS_NULLABLE", "COLUMN_DEFAULT", "EXTRA"}, cols, nil
+			case _MySQLBulkUniqueKeyDefSQL:
+				return []string{"TABLE_NAME", "CONSTRAINT_NAME", "COLUMN_NAME"}, uks, nil
+			case _MySQLBulkIndexDefSQL:
+				return []string{"TABLE_NAME", "INDEX_NAME", "NON_UNIQUE = 0", "LOWER(INDEX_TYPE)", "", "COLUMN_NAME"}, idxs, nil
+			case _MySQLBulkFKDefSQL:
+				return []string{"REFERENCED_TABLE_NAME", "REFERENCED_COLUMN_NAME", "TABLE_NAME", "COLUMN_NAME", "CONSTRAINT_NAME"}, fks, nil
+			}
+			return nil, nil, errors.New("unexpected query")
+		},
+	}
+}
+
+// testMySQLShopColumns columns of cart, product and user as declared in
+// test_mysql.sql
+var testMySQLShopColumns = []struct {
+	table, column  string
+	dataType       string
+	notNull        bool
+	def            *string
+	extra          string
+	pk, unique, fk bool
+	comment        string
+}{
+	{"product", "product_id", "int", true, nil, "auto_increment", true, false, false, "商品ID"},
+	{"product", "product_name", "varchar(255)", true, nil, "", false, false, false, "商品名称"},
+	{"product", "description", "text", false, nil, "", false, false, false, "商品描述"},
+	{"product", "price", "decimal(10,2)", true, nil, "", false, false, false, "商品价格"},
+	{"product", "stock_quantity", "int", true, nil, "", false, false, false, "库存数量"},
+	{"product", "category", "varchar(50)", false, nil, "", false, false, false, "商品类别 "},
+	{"product", "created_at", "timestamp", false, strPtr("CURRENT_TIMESTAMP"), "", false, false, false, "创建时间"},
+	{"product", "updated_at", "timestamp", false, strPtr("CURRENT_TIMESTAMP"), "on update CURRENT_TIMESTAMP", false, false, false, "修改时间"},
+	{"user", "user_id", "int", true, nil, "auto_increment", true, false, false, "用户ID"},
+	{"user", "username", "varchar(50)", true, nil, "", false, false, false, "用户名称"},
+	{"user", "email", "varchar(100)", true, nil, "", false, true, false, "电子邮件"},
+	{"user", "password_hash", "char(60)", true, nil, "", false, false, false, "密码哈希"},
+	{"user", "first_name", "varchar(50)", false, nil, "", false, false, false, "名字"},
+	{"user", "updated_at", "timestamp", false, strPtr("CURRENT_TIMESTAMP"), "on update CURRENT_TIMESTAMP", false, false, false, "更新时间"},
+	{"cart", "cart_id", "int", true, nil, "auto_increment", true, false, false, "购物车ID"},
+	{"cart", "user_id", "int", true, nil, "", false, false, true, "用户ID"},
+	{"cart", "product_id", "int", true, nil, "", false, false, true, "商品ID"},
+	{"cart", "quantity", "int", true, nil, "", false, false, false, "商品数量"},
+	{"cart", "added_at", "timestamp", false, strPtr("CURRENT_TIMESTAMP"), "", false, false, false, "添加时间"},
+}
+
+// checkMySQLShopColumns compare the columns of tbls with testMySQLShopColumns
+func checkMySQLShopColumns(t *testing.T, tbls []*schema.Table) {
+	t.Helper()
+	for _, bc := range testMySQLShopColumns {
+		c, found := schema.FindColumnByName(tbls, bc.table, bc.column)
+		if !found {
+			t.Errorf("%s.%s not loaded", bc.table, bc.column)
+			continue
+		}
+		def := sql.NullString{}
+		if bc.def != nil {
+			def = sql.NullString{String: *bc.def, Valid: true}
+		}
+		if c.DataType != bc.dataType || c.NotNull != bc.notNull || c.Default != def || c.Extra != bc.extra ||
+			c.IsPrimaryKey != bc.pk || c.IsUnique != bc.unique || c.IsForeignKey != bc.fk || c.Comment.String != bc.comment {
+			t.Errorf("%s.%s = %+v", bc.table, bc.column, c)
+		}
+	}
+}
+
+func Test_mysql_columns(t *testing.T) {
+	got, err := newFakePlanter("mysql", fakeMySQLShop().open(), Options{}).LoadTableDef(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(got) != 3 {
+		t.Fatalf("tables = %d, want 3", len(got))
+	}
+	checkMySQLShopColumns(t, got)
+
+	user, _ := schema.FindTableByName(got, "user")
+	if len(user.UniqueKeys) != 1 || user.UniqueKeys[0].Columns[0] != user.Columns[2] {
+		t.Errorf("user unique keys = %+v", user.UniqueKeys)
+	}
+}
+
+// Test_mysql_columns_ddl the DDL driver reads the same columns from the fixture
+func Test_mysql_columns_ddl(t *testing.T) {
+	src, err := os.ReadFile("../test_mysql.sql")
+	if err != nil {
+		t.Fatal(err)
+	}
+	tbls, err := ParseDDL(src)
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkMySQLShopColumns(t, tbls)
+}
+
+func strPtr(s string) *string {
+	return &s
+}
@@ -0,0 +1,480 @@
+package driver
+
+import (
+	"context"
+	"database/sql"
+	_ "github.com/lib/pq" // postgres
+	"github.com/maocatooo/planter/schema"
+	"github.com/pkg/errors"
+)
+
+const _PGSQLcolumDefSelect = `
+SELECT
+    c.relname AS table_name,
+    a.attnum AS field_ordinal,
+    a.attname AS column_name,
+    pd.description AS description,
+    format_type(a.atttypid, a.atttypmod) AS data_type,
+    a.attnotnull AS not_null,
+    EXISTS (
+        SELECT 1 FROM pg_constraint ct
+        WHERE ct.conrelid = c.oid AND ct.contype = 'p' AND a.attnum = ANY(ct.conkey)
+    ) AS is_primary_key,
+    CASE WHEN a.atttypid = ANY ('{int,int8,int2}'::regtype[])
+      AND EXISTS (
+         SELECT 1 FROM pg_attrdef ad
+         WHERE  ad.adrelid = a.attrelid
+         AND    ad.adnum   = a.attnum
+         AND    pg_get_expr(ad.adbin, ad.adrelid) = 'nextval('''
+            || (pg_get_serial_sequence (a.attrelid::regclass::text
+                                      , a.attname))::regclass
+            || '''::regclass)'
+         )
+    THEN CASE a.atttypid
+            WHEN 'int'::regtype  THEN 'serial'
+            WHEN 'int8'::regtype THEN 'bigserial'
+            WHEN 'int2'::regtype THEN 'smallserial'
+         END
+    ELSE format_type(a.atttypid, a.atttypmod)
+    END AS data_type
+FROM pg_attribute a
+JOIN ONLY pg_class c ON c.oid = a.attrelid
+JOIN ONLY pg_namespace n ON n.oid = c.relnamespace
+LEFT JOIN pg_attrdef ad ON ad.adrelid = c.oid AND ad.adnum = a.attnum
+LEFT JOIN pg_description pd ON pd.objoid = a.attrelid AND pd.objsubid = a.attnum
+WHERE a.attisdropped = false
+AND n.nspname = $1
+AND a.attnum > 0
+`
+
+const _PGSQLcolumDefSQL = _PGSQLcolumDefSelect + `AND c.relname = $2
+ORDER BY a.attnum
+`
+
+// _PGSQLBulkColumDefSQL columns of every table of the schema in one query
+const _PGSQLBulkColumDefSQL = _PGSQLcolumDefSelect + `AND c.relkind in ('r','p')
+ORDER BY c.relname, a.attnum
+`
+
+const _PGSQLUniqueKeyDefSelect = `
+SELECT
+    c.relname AS table_name,
+    ct.conname AS constraint_name,
+    a.attname AS column_name
+FROM pg_constraint ct
+JOIN ONLY pg_class c ON c.oid = ct.conrelid
+JOIN ONLY pg_namespace n ON n.oid = c.relnamespace
+CROSS JOIN LATERAL unnest(ct.conkey) WITH ORDINALITY AS k(attnum, seq)
+JOIN pg_attribute a ON a.attrelid = c.oid AND a.attnum = k.attnum
+WHERE ct.contype = 'u'
+AND n.nspname = $1
+`
+
+const _PGSQLUniqueKeyDefSQL = _PGSQLUniqueKeyDefSelect + `AND c.relname = $2
+ORDER BY ct.conname, k.seq
+`
+
+// _PGSQLBulkUniqueKeyDefSQL unique keys of every table of the schema in one query
+const _PGSQLBulkUniqueKeyDefSQL = _PGSQLUniqueKeyDefSelect + `ORDER BY c.relname, ct.conname, k.seq
+`
+
+const _PGSQLIndexDefSelect = `
+SELECT
+    c.relname AS table_name,
+    i.relname AS index_name,
+    ix.indisunique AS is_unique,
+    am.amname AS method,
+    COALESCE(pg_get_expr(ix.indpred, ix.indrelid), '') AS predicate,
+    pg_get_indexdef(ix.indexrelid, k.seq, true) AS column_name
+FROM pg_index ix
+JOIN ONLY pg_class c ON c.oid = ix.indrelid
+JOIN pg_class i ON i.oid = ix.indexrelid
+JOIN pg_am am ON am.oid = i.relam
+JOIN ONLY pg_namespace n ON n.oid = c.relnamespace
+CROSS JOIN LATERAL generate_series(1, COALESCE((row_to_json(ix)->>'indnkeyatts')::int, ix.indnatts)) AS k(seq)
+WHERE NOT ix.indisprimary
+AND n.nspname = $1
+`
+
+const _PGSQLIndexDefSQL = _PGSQLIndexDefSelect + `AND c.relname = $2
+ORDER BY i.relname, k.seq
+`
+
+// _PGSQLBulkIndexDefSQL indexes of every table of the schema in one query
+const _PGSQLBulkIndexDefSQL = _PGSQLIndexDefSelect + `ORDER BY c.relname, i.relname, k.seq
+`
+
+const _PGSQLTableDefSQL = `
+SELECT
+  c.relname AS table_name,
+  pd.description AS description
+FROM pg_class c
+JOIN ONLY pg_namespace n
+ON n.oid = c.relnamespace
+LEFT JOIN pg_description pd ON pd.objoid = c.oid AND pd.objsubid = 0
+WHERE n.nspname = $1
+AND c.relkind in ('r','p') AND NOT COALESCE((row_to_json(c)->>'relispartition')::boolean,false)
+ORDER BY c.relname
+`
+
+const _PGSQLFKDefSelect = `
+select
+  con.relname as "child_table"
+  , att2.attname as "child_column"
+  , cl.relname as "parent_table"
+  , att.attname as "parent_column"
+  , con.conname
+  , case 
+      when pi.indisprimary is null then false
+      else pi.indisprimary
+    end as "is_parent_pk"
+  , case 
+      when ci.indisprimary is null then false
+      else ci.indisprimary
+    end as "is_child_pk"
+from (
+  select 
+    unnest(con1.conkey) as "parent"
+    , unnest(con1.confkey) as "child"
+    , con1.confrelid
+    , con1.conrelid
+    , generate_subscripts(con1.conkey, 1) as "pos"
+    , con1.conname
+    , cl.relname
+  from pg_class cl
+  join pg_namespace ns on cl.relnamespace = ns.oid
+  join pg_constraint con1 on con1.conrelid = cl.oid
+  where ns.nspname = $1
+`
+
+const _PGSQLFKDefJoin = `  and con1.contype = 'f'
+  and (coalesce((row_to_json(con1)->>'conparentid'),'0')::oid) = 0
+) con
+join pg_attribute att
+on att.attrelid = con.confrelid and att.attnum = con.child
+left outer join pg_index pi
+on att.attrelid = pi.indrelid and att.attnum = any(pi.indkey) and pi.indisprimary
+join pg_class cl
+on cl.oid = con.confrelid
+join pg_attribute att2
+on att2.attrelid = con.conrelid and att2.attnum = con.parent
+left outer join pg_index ci
+on att2.attrelid = ci.indrelid and att2.attnum = any(ci.indkey) and ci.indisprimary
+`
+
+const _PGSQLFKDefSQL = _PGSQLFKDefSelect + `  and cl.relname = $2
+` + _PGSQLFKDefJoin + `order by con.conname, con.pos
+`
+
+// _PGSQLBulkFKDefSQL foreign keys of every table of the schema in one query
+const _PGSQLBulkFKDefSQL = _PGSQLFKDefSelect + _PGSQLFKDefJoin + `order by con.relname, con.conname, con.pos
+`
+
+type postgres struct {
+	db               Queryer
+	schema           string
+	filter           func(string) bool
+	concurrency      int
+	_currentDataBase string
+}
+
+func NewPostgres(opts Options) Planter {
+	return &postgres{
+		schema:      opts.Schema,
+		filter:      opts.Filter,
+		concurrency: opts.Concurrency,
+	}
+}
+
+func (m *postgres) OpenDB(connStr string) error {
+	conn, err := openDB("postgres", connStr, m.concurrency)
+	if err != nil {
+		return err
+	}
+	m.db = conn
+	return nil
+}
+
+// CheckInclusion sample fk, the tables are read from the schema of m
+func (m *postgres) CheckInclusion(ctx context.Context, fk *schema.ForeignKey, limit int) (int, int, error) {
+	q := quoteIdent(`"`)
+	table := func(name string) string { return q(m.schema) + "." + q(name) }
+	return checkInclusion(ctx, m.db, inclusionSQL(fk, "", "$1", table, q), limit)
+}
+
+// scanColumnDefs scan column rows, grouped by table name
+func (m *postgres) scanColumnDefs(colDefs *sql.Rows) (map[string][]*schema.Column, error) {
+	cols := map[string][]*schema.Column{}
+	for colDefs.Next() {
+		var (
+			table string
+			c     schema.Column
+		)
+		err := colDefs.Scan(
+			&table,
+			&c.FieldOrdinal,
+			&c.Name,
+			&c.Comment,
+			&c.DataType,
+			&c.NotNull,
+			&c.IsPrimaryKey,
+			&c.DDLType,
+		)
+		if err != nil {
+			return nil, err
+		}
+		c.Comment.String = stripCommentSuffix(c.Comment.String)
+		cols[table] = append(cols[table], &c)
+	}
+	return cols, colDefs.Err()
+}
+
+// loadColumnDef load Postgres column definition
+func (m *postgres) loadColumnDef(ctx context.Context, table string) ([]*schema.Column, error) {
+	colDefs, err := m.db.QueryContext(ctx, _PGSQLcolumDefSQL, m.schema, table)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to load column def of %s", table)
+	}
+	defer colDefs.Close()
+	cols, err := m.scanColumnDefs(colDefs)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to load column def of %s", table)
+	}
+	return cols[table], nil
+}
+
+// scanForeignKeyDefs scan fk rows, grouped by source table name
+func (m *postgres) scanForeignKeyDefs(fkDefs *sql.Rows) (map[string][]*schema.ForeignKey, error) {
+	fks := map[string][]*schema.ForeignKey{}
+	for fkDefs.Next() {
+		var fk schema.ForeignKey
+		err := fkDefs.Scan(
+			&fk.SourceTableName,
+			&fk.SourceColName,
+			&fk.TargetTableName,
+			&fk.TargetColName,
+			&fk.ConstraintName,
+			&fk.IsTargetColPrimaryKey,
+			&fk.IsSourceColPrimaryKey,
+		)
+		if err != nil {
+			return nil, err
+		}
+		fks[fk.SourceTableName] = append(fks[fk.SourceTableName], &fk)
+	}
+	return fks, fkDefs.Err()
+}
+
+// linkForeignKeys resolve the tables and columns of the fks of tbl and group
+// the column pairs by constraint, fks to tables dropped by the filter are skipped
+func (m *postgres) linkForeignKeys(tbls []*schema.Table, tbl *schema.Table, fks []*schema.ForeignKey) ([]*schema.ForeignKey, error) {
+	var linked []*schema.ForeignKey
+	for _, fk := range fks {
+		fk.SourceTable = tbl
+		targetTbl, found := schema.FindTableByName(tbls, fk.TargetTableName)
+		if !found {
+			if m.filter != nil && !m.filter(fk.TargetTableName) {
+				continue
+			}
+			return nil, errors.Errorf("%s: %s not found", fk.ConstraintName, fk.TargetTableName)
+		}
+		fk.TargetTable = targetTbl
+		targetCol, found := schema.FindColumnByName(tbls, fk.TargetTableName, fk.TargetColName)
+		if !found {
+			return nil, errors.Errorf("%s: %s.%s not found", fk.ConstraintName, fk.TargetTableName, fk.TargetColName)
+		}
+		fk.TargetColumn = targetCol
+		sourceCol, found := schema.FindColumnByName(tbls, fk.SourceTableName, fk.SourceColName)
+		if !found {
+			return nil, errors.Errorf("%s: %s.%s not found", fk.ConstraintName, fk.SourceTableName, fk.SourceColName)
+		}
+		sourceCol.IsForeignKey = true
+		fk.SourceColumn = sourceCol
+		linked = append(linked, fk)
+	}
+	return schema.GroupForeignKeys(linked), nil
+}
+
+// loadForeignKeyDef load Postgres fk definition, linked by linkForeignKeys once
+// all columns are loaded
+func (m *postgres) loadForeignKeyDef(ctx context.Context, tbl *schema.Table) ([]*schema.ForeignKey, error) {
+	fkDefs, err := m.db.QueryContext(ctx, _PGSQLFKDefSQL, m.schema, tbl.Name)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to load fk def of %s", tbl.Name)
+	}
+	defer fkDefs.Close()
+	fks, err := m.scanForeignKeyDefs(fkDefs)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to load fk def of %s", tbl.Name)
+	}
+	return fks[tbl.Name], nil
+}
+
+// loadUniqueKeyDef load the unique keys of tbl, columns must be loaded
+func (m *postgres) loadUniqueKeyDef(ctx context.Context, tbl *schema.Table) error {
+	ukDefs, err := m.db.QueryContext(ctx, _PGSQLUniqueKeyDefSQL, m.schema, tbl.Name)
+	if err != nil {
+		return errors.Wrapf(err, "failed to load unique key def of %s", tbl.Name)
+	}
+	defer ukDefs.Close()
+	uks, err := scanUniqueKeyDefs(ukDefs)
+	if err != nil {
+		return errors.Wrapf(err, "failed to load unique key def of %s", tbl.Name)
+	}
+	for _, uk := range uks[tbl.Name] {
+		if err := addUniqueKey(tbl, uk); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// loadIndexDef load the indexes of tbl
+func (m *postgres) loadIndexDef(ctx context.Context, tbl *schema.Table) error {
+	idxDefs, err := m.db.QueryContext(ctx, _PGSQLIndexDefSQL, m.schema, tbl.Name)
+	if err != nil {
+		return errors.Wrapf(err, "failed to load index def of %s", tbl.Name)
+	}
+	defer idxDefs.Close()
+	idxs, err := scanIndexDefs(idxDefs)
+	if err != nil {
+		return errors.Wrapf(err, "failed to load index def of %s", tbl.Name)
+	}
+	tbl.Indexes = idxs[tbl.Name]
+	return nil
+}
+
+// loadTableDefs load the tables kept by the filter, columns and fks not loaded yet
+func (m *postgres) loadTableDefs(ctx context.Context) ([]*schema.Table, error) {
+	tbDefs, err := m.db.QueryContext(ctx, _PGSQLTableDefSQL, m.schema)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to load table def")
+	}
+	defer tbDefs.Close()
+	var tbls []*schema.Table
+	for tbDefs.Next() {
+		t := &schema.Table{}
+		err := tbDefs.Scan(
+			&t.Name,
+			&t.Comment,
+		)
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to scan table def")
+		}
+		if m.filter != nil && !m.filter(t.Name) {
+			continue
+		}
+		tbls = append(tbls, t)
+	}
+	if err := tbDefs.Err(); err != nil {
+		return nil, errors.Wrap(err, "failed to load table def")
+	}
+	return tbls, nil
+}
+
+// loadBulk load the columns, unique keys, indexes and fks of all tables with one query each
+func (m *postgres) loadBulk(ctx context.Context, tbls []*schema.Table) error {
+	colDefs, err := m.db.QueryContext(ctx, _PGSQLBulkColumDefSQL, m.schema)
+	if err != nil {
+		return errors.Wrapf(err, "failed to load column defs of schema %s", tableSet(m.schema, tbls))
+	}
+	defer colDefs.Close()
+	cols, err := m.scanColumnDefs(colDefs)
+	if err != nil {
+		return errors.Wrapf(err, "failed to load column defs of schema %s", tableSet(m.schema, tbls))
+	}
+	colDefs.Close()
+	for _, tbl := range tbls {
+		tbl.Columns = cols[tbl.Name]
+	}
+
+	ukDefs, err := m.db.QueryContext(ctx, _PGSQLBulkUniqueKeyDefSQL, m.schema)
+	if err != nil {
+		return errors.Wrapf(err, "failed to load unique key defs of schema %s", tableSet(m.schema, tbls))
+	}
+	defer ukDefs.Close()
+	uks, err := scanUniqueKeyDefs(ukDefs)
+	if err != nil {
+		return errors.Wrapf(err, "failed to load unique key defs of schema %s", tableSet(m.schema, tbls))
+	}
+	ukDefs.Close()
+	for _, tbl := range tbls {
+		for _, uk := range uks[tbl.Name] {
+			if err := addUniqueKey(tbl, uk); err != nil {
+				return err
+			}
+		}
+	}
+
+	idxDefs, err := m.db.QueryContext(ctx, _PGSQLBulkIndexDefSQL, m.schema)
+	if err != nil {
+		return errors.Wrapf(err, "failed to load index defs of schema %s", tableSet(m.schema, tbls))
+	}
+	defer idxDefs.Close()
+	idxs, err := scanIndexDefs(idxDefs)
+	if err != nil {
+		return errors.Wrapf(err, "failed to load index defs of schema %s", tableSet(m.schema, tbls))
+	}
+	idxDefs.Close()
+	for _, tbl := range tbls {
+		tbl.Indexes = idxs[tbl.Name]
+	}
+
+	fkDefs, err := m.db.QueryContext(ctx, _PGSQLBulkFKDefSQL, m.schema)
+	if err != nil {
+		return errors.Wrapf(err, "failed to load fk defs of schema %s", tableSet(m.schema, tbls))
+	}
+	defer fkDefs.Close()
+	fks, err := m.scanForeignKeyDefs(fkDefs)
+	if err != nil {
+		return errors.Wrapf(err, "failed to load fk defs of schema %s", tableSet(m.schema, tbls))
+	}
+	for _, tbl := range tbls {
+		if tbl.ForeingKeys, err = m.linkForeignKeys(tbls, tbl, fks[tbl.Name]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// LoadTableDef load Postgres table definition, the whole schema is loaded with
+// bulk catalog queries, filtered tables are queried one by one on up to
+// Options.Concurrency connections
+func (m *postgres) LoadTableDef(ctx context.Context) ([]*schema.Table, error) {
+	tbls, err := m.loadTableDefs(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if m.filter == nil {
+		if err := m.loadBulk(ctx, tbls); err != nil {
+			return nil, err
+		}
+		return tbls, nil
+	}
+
+	fks := make([][]*schema.ForeignKey, len(tbls))
+	err = parallel(ctx, m.concurrency, len(tbls), func(ctx context.Context, i int) error {
+		cols, err := m.loadColumnDef(ctx, tbls[i].Name)
+		if err != nil {
+			return err
+		}
+		tbls[i].Columns = cols
+		if err := m.loadUniqueKeyDef(ctx, tbls[i]); err != nil {
+			return err
+		}
+		if err := m.loadIndexDef(ctx, tbls[i]); err != nil {
+			return err
+		}
+		fks[i], err = m.loadForeignKeyDef(ctx, tbls[i])
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	for i, tbl := range tbls {
+		if tbl.ForeingKeys, err = m.linkForeignKeys(tbls, tbl, fks[i]); err != nil {
+			return nil, err
+		}
+	}
+	return tbls, nil
+}
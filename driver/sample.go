@@ -0,0 +1,102 @@
+package driver
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/maocatooo/planter/schema"
+	"github.com/pkg/errors"
+)
+
+// InclusionChecker implemented by the database drivers, looks up values of a
+// foreign key column in the referenced column
+type InclusionChecker interface {
+	// CheckInclusion read up to limit non null values of the first source
+	// column of fk and count how many of them exist in its target column
+	CheckInclusion(ctx context.Context, fk *schema.ForeignKey, limit int) (sampled, found int, err error)
+}
+
+// SampleOptions settings of SampleForeignKeys
+type SampleOptions struct {
+	// Rows values read from the source column of every inferred foreign key
+	Rows int
+	// Timeout limit of every sampling query, a foreign key whose query runs
+	// longer is left unsampled, 0 waits forever
+	Timeout time.Duration
+	// MinConfidence drop the sampled foreign keys with a lower share of values
+	// found in the referenced column, see schema.DropUnconfirmed
+	MinConfidence float64
+	// Concurrency number of sampling queries run at once, Default 1
+	Concurrency int
+}
+
+// SampleForeignKeys check the Inferred foreign keys of tbls against the data:
+// sample the values of the source column, set Sampled and Confidence from the
+// share found in the target column and drop the foreign keys below
+// MinConfidence. Empty columns and timed out queries leave the foreign key
+// unsampled
+func SampleForeignKeys(ctx context.Context, c InclusionChecker, tbls []*schema.Table, opts SampleOptions) error {
+	if opts.Rows <= 0 {
+		return errors.Errorf("invalid sample size %d", opts.Rows)
+	}
+	var fks []*schema.ForeignKey
+	for _, tbl := range tbls {
+		for _, fk := range tbl.ForeingKeys {
+			if fk.Inferred {
+				fks = append(fks, fk)
+			}
+		}
+	}
+	err := parallel(ctx, opts.Concurrency, len(fks), func(ctx context.Context, i int) error {
+		fk := fks[i]
+		qctx, cancel := ctx, context.CancelFunc(func() {})
+		if opts.Timeout > 0 {
+			qctx, cancel = context.WithTimeout(ctx, opts.Timeout)
+		}
+		sampled, found, err := c.CheckInclusion(qctx, fk, opts.Rows)
+		cancel()
+		if err != nil {
+			if ctx.Err() == nil && (qctx.Err() != nil || errors.Cause(err) == context.DeadlineExceeded) {
+				return nil
+			}
+			return errors.Wrapf(err, "failed to sample %s.%s", fk.SourceTableName, fk.SourceColName)
+		}
+		if sampled > 0 {
+			fk.Sampled, fk.Confidence = sampled, float64(found)/float64(sampled)
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	schema.DropUnconfirmed(tbls, opts.MinConfidence)
+	return nil
+}
+
+// inclusionSQL count the rows of a sample of the first source column of fk
+// and the ones found in the target column. Only the first param rows are
+// read, the target column is a primary key so every row matches once at most.
+// hint is written after SELECT, table and ident quote names
+func inclusionSQL(fk *schema.ForeignKey, hint, param string, table, ident func(string) string) string {
+	p := fk.ColumnPairs()[0]
+	src, tgt := ident(p.SourceColName), ident(p.TargetColName)
+	return fmt.Sprintf(`SELECT %sCOUNT(*), COUNT(t.%s) FROM (SELECT %s AS v FROM %s WHERE %s IS NOT NULL LIMIT %s) s LEFT JOIN %s t ON t.%s = s.v`,
+		hint, tgt, src, table(fk.SourceTableName), src, param, table(fk.TargetTableName), tgt)
+}
+
+// checkInclusion run an inclusionSQL query
+func checkInclusion(ctx context.Context, db Queryer, query string, limit int) (sampled, found int, err error) {
+	if err := db.QueryRowContext(ctx, query, limit).Scan(&sampled, &found); err != nil {
+		return 0, 0, err
+	}
+	return sampled, found, nil
+}
+
+// quoteIdent quote name with q, doubling the quotes inside it
+func quoteIdent(q string) func(string) string {
+	return func(name string) string {
+		return q + strings.ReplaceAll(name, q, q+q) + q
+	}
+}
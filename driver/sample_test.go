@@ -0,0 +1,148 @@
+package driver
+
+import (
+	"context"
+	"database/sql"
+	sqldriver "database/sql/driver"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/maocatooo/planter/schema"
+)
+
+// testSampleSchema legacy schema without constraints, every orders.user_id
+// exists in users, most orders.coupon_id do not exist in coupon and
+// orders.gift_id is always null
+const testSampleSchema = `
+CREATE TABLE users (id INTEGER PRIMARY KEY);
+CREATE TABLE coupon (id INTEGER PRIMARY KEY);
+CREATE TABLE gift (id INTEGER PRIMARY KEY);
+CREATE TABLE orders (id INTEGER PRIMARY KEY, user_id INTEGER, coupon_id INTEGER, gift_id INTEGER);
+INSERT INTO users VALUES (1), (2), (3);
+INSERT INTO coupon VALUES (1);
+INSERT INTO orders VALUES (1, 1, 1, NULL), (2, 2, 7, NULL), (3, 3, 8, NULL), (4, NULL, 9, NULL);
+`
+
+func Test_SampleForeignKeys(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "legacy.db")
+	db, err := sql.Open("sqlite3", path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	if _, err := db.Exec(testSampleSchema); err != nil {
+		t.Fatal(err)
+	}
+
+	p := NewSqlite(Options{})
+	if err := p.OpenDB(path); err != nil {
+		t.Fatal(err)
+	}
+	ctx := context.Background()
+	tbls, err := p.LoadTableDef(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	schema.InferForeignKeys(tbls, schema.InferOptions{})
+	err = SampleForeignKeys(ctx, p.(InclusionChecker), tbls, SampleOptions{Rows: 100, Timeout: time.Second, MinConfidence: 0.9})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	orders, _ := schema.FindTableByName(tbls, "orders")
+	got := map[string]*schema.ForeignKey{}
+	for _, fk := range orders.ForeingKeys {
+		got[fk.SourceColName] = fk
+	}
+	if fk := got["user_id"]; fk == nil || fk.Sampled != 3 || fk.Confidence != 1 {
+		t.Errorf("user_id: %+v", fk)
+	}
+	if fk := got["gift_id"]; fk == nil || fk.Sampled != 0 {
+		t.Errorf("gift_id has no values and should be kept unsampled: %+v", fk)
+	}
+	if fk := got["coupon_id"]; fk != nil || orders.Columns[2].IsForeignKey {
+		t.Errorf("coupon_id found 1 of 4 values and should be dropped: %+v", fk)
+	}
+
+	if err := SampleForeignKeys(ctx, p.(InclusionChecker), tbls, SampleOptions{}); err == nil {
+		t.Error("expected error for sample size 0")
+	}
+}
+
+func Test_SampleForeignKeys_timeout(t *testing.T) {
+	var queries []string
+	fake := &fakeDB{
+		latency: 200 * time.Millisecond,
+		handle: func(query string, args []sqldriver.NamedValue) ([]string, [][]sqldriver.Value, error) {
+			queries = append(queries, query)
+			return []string{"sampled", "found"}, [][]sqldriver.Value{{int64(10), int64(0)}}, nil
+		},
+	}
+	conn := fake.open()
+	defer conn.Close()
+
+	users := &schema.Table{Name: "users", Columns: []*schema.Column{{Name: "id", DataType: "int", IsPrimaryKey: true}}}
+	orders := &schema.Table{Name: "orders", Columns: []*schema.Column{
+		{Name: "id", DataType: "int", IsPrimaryKey: true},
+		{Name: "user_id", DataType: "int"},
+	}}
+	tbls := []*schema.Table{users, orders}
+	schema.InferForeignKeys(tbls, schema.InferOptions{})
+
+	m := &mysql{db: conn}
+	if err := SampleForeignKeys(context.Background(), m, tbls, SampleOptions{Rows: 10, Timeout: 10 * time.Millisecond, MinConfidence: 0.9}); err != nil {
+		t.Fatal(err)
+	}
+	if len(orders.ForeingKeys) != 1 || orders.ForeingKeys[0].Sampled != 0 {
+		t.Errorf("timed out foreign key should be kept unsampled: %+v", orders.ForeingKeys)
+	}
+	if len(queries) != 0 {
+		t.Errorf("query answered after its timeout: %v", queries)
+	}
+
+	fake.latency = 0
+	if err := SampleForeignKeys(context.Background(), m, tbls, SampleOptions{Rows: 10, Timeout: time.Second, MinConfidence: 0.9}); err != nil {
+		t.Fatal(err)
+	}
+	if len(orders.ForeingKeys) != 0 {
+		t.Errorf("foreign key without matching values kept: %+v", orders.ForeingKeys)
+	}
+	want := "SELECT /*+ MAX_EXECUTION_TIME("
+	if len(queries) != 1 || !strings.HasPrefix(queries[0], want) || !strings.Contains(queries[0], "FROM `orders` WHERE `user_id` IS NOT NULL LIMIT ?") {
+		t.Errorf("unexpected query %v", queries)
+	}
+}
+
+func Test_mysql_CheckInclusion_deadline(t *testing.T) {
+	var queries []string
+	fake := &fakeDB{
+		handle: func(query string, args []sqldriver.NamedValue) ([]string, [][]sqldriver.Value, error) {
+			queries = append(queries, query)
+			return []string{"sampled", "found"}, [][]sqldriver.Value{{int64(1), int64(1)}}, nil
+		},
+	}
+	conn := fake.open()
+	defer conn.Close()
+	m := &mysql{db: conn}
+	fk := &schema.ForeignKey{SourceTableName: "orders", SourceColName: "user_id", TargetTableName: "users", TargetColName: "id"}
+
+	ctx, cancel := context.WithDeadline(context.Background(), time.Now().Add(-time.Second))
+	defer cancel()
+	if _, _, err := m.CheckInclusion(ctx, fk, 10); err != context.DeadlineExceeded {
+		t.Errorf("err = %v, want deadline exceeded", err)
+	}
+	if len(queries) != 0 {
+		t.Errorf("query run past its deadline: %v", queries)
+	}
+
+	for left, want := range map[time.Duration]string{
+		500 * time.Microsecond:  "/*+ MAX_EXECUTION_TIME(1) */ ",
+		1500 * time.Millisecond: "/*+ MAX_EXECUTION_TIME(1500) */ ",
+	} {
+		if got := maxExecutionTimeHint(left); got != want {
+			t.Errorf("maxExecutionTimeHint(%s) = %q, want %q", left, got, want)
+		}
+	}
+}
@@ -0,0 +1,54 @@
+package driver
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"os"
+
+	"github.com/maocatooo/planter/schema"
+	"github.com/pkg/errors"
+)
+
+// snapshot loads table definitions from a json snapshot written with --format json
+type snapshot struct {
+	src []byte
+}
+
+func NewSnapshot() Planter {
+	return &snapshot{}
+}
+
+// OpenDB read the snapshot file, "-" reads stdin
+func (m *snapshot) OpenDB(connStr string) error {
+	var (
+		src []byte
+		err error
+	)
+	if connStr == "-" {
+		src, err = io.ReadAll(os.Stdin)
+	} else {
+		src, err = os.ReadFile(connStr)
+	}
+	if err != nil {
+		return errors.Wrap(err, "failed to read snapshot")
+	}
+	m.src = src
+	return nil
+}
+
+// LoadTableDef decode snapshot table definition
+func (m *snapshot) LoadTableDef(ctx context.Context) ([]*schema.Table, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	var s schema.Snapshot
+	if err := json.Unmarshal(m.src, &s); err != nil {
+		return nil, errors.Wrap(err, "failed to decode snapshot")
+	}
+	tbls, err := s.ToTables()
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to load snapshot")
+	}
+	return tbls, nil
+}
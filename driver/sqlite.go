@@ -0,0 +1,350 @@
+package driver
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/maocatooo/planter/schema"
+	_ "github.com/mattn/go-sqlite3" // sqlite
+	"github.com/pkg/errors"
+)
+
+const _SQLiteTableDefSQL = `
+SELECT
+    name
+FROM
+    sqlite_master
+WHERE
+    type = 'table' AND name NOT LIKE 'sqlite_%'
+ORDER BY name
+`
+
+const _SQLiteColumDefSQL = `
+SELECT
+    cid, name, type, "notnull", pk
+FROM
+    pragma_table_info(?)
+ORDER BY cid
+`
+
+const _SQLiteFKDefSQL = `
+SELECT
+    id, seq, "table", "from", "to"
+FROM
+    pragma_foreign_key_list(?)
+ORDER BY id, seq
+`
+
+// _SQLiteUniqueKeyDefSQL UNIQUE constraints are backed by indexes of origin u,
+// named sqlite_autoindex_<table>_<n>
+const _SQLiteUniqueKeyDefSQL = `
+SELECT
+    m.name, il.name, ii.name
+FROM
+    sqlite_master m
+    JOIN pragma_index_list(m.name) il
+    JOIN pragma_index_info(il.name) ii
+WHERE
+    m.name = ? AND il.origin = 'u'
+ORDER BY il.name, ii.seqno
+`
+
+// _SQLiteIndexDefSQL SQLite only has btree indexes, partial indexes return
+// their CREATE INDEX statement, the predicate is cut by partialPredicate
+const _SQLiteIndexDefSQL = `
+SELECT
+    m.name, il.name, il."unique", 'btree',
+    CASE WHEN il.partial THEN s.sql ELSE '' END,
+    ii.name
+FROM
+    sqlite_master m
+    JOIN pragma_index_list(m.name) il
+    JOIN pragma_index_info(il.name) ii
+    LEFT JOIN sqlite_master s ON s.type = 'index' AND s.name = il.name
+WHERE
+    m.name = ? AND il.origin <> 'pk'
+ORDER BY il.name, ii.seqno
+`
+
+type sqlite struct {
+	db          Queryer
+	filter      func(string) bool
+	concurrency int
+	// mu guards pkOrder, written by the concurrent column loads
+	mu sync.Mutex
+	// pkOrder pk column names per table in PRIMARY KEY declaration order
+	pkOrder map[string][]string
+}
+
+func NewSqlite(opts Options) Planter {
+	return &sqlite{
+		filter:      opts.Filter,
+		concurrency: opts.Concurrency,
+		pkOrder:     map[string][]string{},
+	}
+}
+
+func (m *sqlite) OpenDB(connStr string) error {
+	conn, err := openDB("sqlite3", connStr, m.concurrency)
+	if err != nil {
+		return err
+	}
+	m.db = conn
+	return nil
+}
+
+// CheckInclusion sample fk
+func (m *sqlite) CheckInclusion(ctx context.Context, fk *schema.ForeignKey, limit int) (int, int, error) {
+	q := quoteIdent(`"`)
+	return checkInclusion(ctx, m.db, inclusionSQL(fk, "", "?", q, q), limit)
+}
+
+// loadColumnDef load SQLite column definition
+func (m *sqlite) loadColumnDef(ctx context.Context, table string) ([]*schema.Column, error) {
+	colDefs, err := m.db.QueryContext(ctx, _SQLiteColumDefSQL, table)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to load column def of %s", table)
+	}
+	defer colDefs.Close()
+	var (
+		cols []*schema.Column
+		pks  = map[int]string{}
+	)
+	for colDefs.Next() {
+		var (
+			c  schema.Column
+			pk int
+		)
+		err := colDefs.Scan(
+			&c.FieldOrdinal,
+			&c.Name,
+			&c.DataType,
+			&c.NotNull,
+			&pk,
+		)
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to scan column def of %s", table)
+		}
+		// cid is zero based, keep ordinals aligned with the other drivers
+		c.FieldOrdinal++
+		c.DDLType = c.DataType
+		c.IsPrimaryKey = pk > 0
+		if c.IsPrimaryKey {
+			pks[pk] = c.Name
+		}
+		cols = append(cols, &c)
+	}
+	if err := colDefs.Err(); err != nil {
+		return nil, errors.Wrapf(err, "failed to load column def of %s", table)
+	}
+	keys := make([]int, 0, len(pks))
+	for k := range pks {
+		keys = append(keys, k)
+	}
+	sort.Ints(keys)
+	ordered := make([]string, 0, len(keys))
+	for _, k := range keys {
+		ordered = append(ordered, pks[k])
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.pkOrder[table] = ordered
+	return cols, nil
+}
+
+// loadUniqueKeyDef load the unique keys of tbl, columns must be loaded
+func (m *sqlite) loadUniqueKeyDef(ctx context.Context, tbl *schema.Table) error {
+	ukDefs, err := m.db.QueryContext(ctx, _SQLiteUniqueKeyDefSQL, tbl.Name)
+	if err != nil {
+		return errors.Wrapf(err, "failed to load unique key def of %s", tbl.Name)
+	}
+	defer ukDefs.Close()
+	uks, err := scanUniqueKeyDefs(ukDefs)
+	if err != nil {
+		return errors.Wrapf(err, "failed to load unique key def of %s", tbl.Name)
+	}
+	for _, uk := range uks[tbl.Name] {
+		if err := addUniqueKey(tbl, uk); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// loadIndexDef load the indexes of tbl
+func (m *sqlite) loadIndexDef(ctx context.Context, tbl *schema.Table) error {
+	idxDefs, err := m.db.QueryContext(ctx, _SQLiteIndexDefSQL, tbl.Name)
+	if err != nil {
+		return errors.Wrapf(err, "failed to load index def of %s", tbl.Name)
+	}
+	defer idxDefs.Close()
+	idxs, err := scanIndexDefs(idxDefs)
+	if err != nil {
+		return errors.Wrapf(err, "failed to load index def of %s", tbl.Name)
+	}
+	tbl.Indexes = idxs[tbl.Name]
+	for _, ix := range tbl.Indexes {
+		ix.Predicate = partialPredicate(ix.Predicate)
+	}
+	return nil
+}
+
+// sqliteIndexWhere the WHERE following the column list of CREATE INDEX, on the
+// same line or not
+var sqliteIndexWhere = regexp.MustCompile(`(?is)^.*?\)\s*WHERE\s+(.*)$`)
+
+// partialPredicate predicate of a partial CREATE INDEX statement
+func partialPredicate(stmt string) string {
+	if m := sqliteIndexWhere.FindStringSubmatch(stmt); m != nil {
+		return strings.TrimSpace(m[1])
+	}
+	return ""
+}
+
+type sqliteFK struct {
+	ID         int
+	Seq        int
+	TargetName string
+	SourceCol  string
+	TargetCol  sql.NullString
+}
+
+// loadForeignKeyDef load SQLite fk definition, linked by linkForeignKeys
+// once all columns are loaded
+func (m *sqlite) loadForeignKeyDef(ctx context.Context, tbl *schema.Table) ([]sqliteFK, error) {
+	fkDefs, err := m.db.QueryContext(ctx, _SQLiteFKDefSQL, tbl.Name)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to load fk def of %s", tbl.Name)
+	}
+	defer fkDefs.Close()
+	var defs []sqliteFK
+	for fkDefs.Next() {
+		var d sqliteFK
+		err := fkDefs.Scan(
+			&d.ID,
+			&d.Seq,
+			&d.TargetName,
+			&d.SourceCol,
+			&d.TargetCol,
+		)
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to scan fk def of %s", tbl.Name)
+		}
+		defs = append(defs, d)
+	}
+	if err := fkDefs.Err(); err != nil {
+		return nil, errors.Wrapf(err, "failed to load fk def of %s", tbl.Name)
+	}
+	return defs, nil
+}
+
+// linkForeignKeys resolve the tables and columns of the fks of tbl and group
+// the column pairs by constraint, fks to tables dropped by the filter are skipped
+func (m *sqlite) linkForeignKeys(tbls []*schema.Table, tbl *schema.Table, defs []sqliteFK) ([]*schema.ForeignKey, error) {
+	var fks []*schema.ForeignKey
+	for _, d := range defs {
+		name := sqliteConstraintName(tbl.Name, d.ID)
+		targetTbl, found := schema.FindTableByName(tbls, d.TargetName)
+		if !found {
+			if m.filter != nil && !m.filter(d.TargetName) {
+				continue
+			}
+			return nil, errors.Errorf("%s: %s not found", name, d.TargetName)
+		}
+		// REFERENCES t without a column list points at the primary key of t
+		targetColName := d.TargetCol.String
+		if !d.TargetCol.Valid || targetColName == "" {
+			pks := m.pkOrder[targetTbl.Name]
+			if d.Seq >= len(pks) {
+				return nil, errors.Errorf("%s: %s has no primary key column for %s.%s", name, d.TargetName, tbl.Name, d.SourceCol)
+			}
+			targetColName = pks[d.Seq]
+		}
+		targetCol, found := schema.FindColumnByName(tbls, d.TargetName, targetColName)
+		if !found {
+			return nil, errors.Errorf("%s: %s.%s not found", name, d.TargetName, targetColName)
+		}
+		sourceCol, found := schema.FindColumnByName(tbls, tbl.Name, d.SourceCol)
+		if !found {
+			return nil, errors.Errorf("%s: %s.%s not found", name, tbl.Name, d.SourceCol)
+		}
+		sourceCol.IsForeignKey = true
+		fks = append(fks, &schema.ForeignKey{
+			ConstraintName:        name,
+			SourceTableName:       tbl.Name,
+			SourceColName:         sourceCol.Name,
+			IsSourceColPrimaryKey: sourceCol.IsPrimaryKey,
+			SourceTable:           tbl,
+			SourceColumn:          sourceCol,
+			TargetTableName:       targetTbl.Name,
+			TargetColName:         targetCol.Name,
+			IsTargetColPrimaryKey: targetCol.IsPrimaryKey,
+			TargetTable:           targetTbl,
+			TargetColumn:          targetCol,
+		})
+	}
+	return schema.GroupForeignKeys(fks), nil
+}
+
+// sqliteConstraintName SQLite does not expose fk constraint names, derive a stable one
+func sqliteConstraintName(table string, id int) string {
+	return fmt.Sprintf("fk_%s_%d", table, id)
+}
+
+// LoadTableDef load SQLite table definition
+func (m *sqlite) LoadTableDef(ctx context.Context) ([]*schema.Table, error) {
+	tbDefs, err := m.db.QueryContext(ctx, _SQLiteTableDefSQL)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to load table def")
+	}
+	defer tbDefs.Close()
+	var tbls []*schema.Table
+	for tbDefs.Next() {
+		t := &schema.Table{}
+		err := tbDefs.Scan(
+			&t.Name,
+		)
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to scan table def")
+		}
+		if m.filter != nil && !m.filter(t.Name) {
+			continue
+		}
+		tbls = append(tbls, t)
+	}
+	if err := tbDefs.Err(); err != nil {
+		return nil, errors.Wrap(err, "failed to load table def")
+	}
+	tbDefs.Close()
+
+	defs := make([][]sqliteFK, len(tbls))
+	err = parallel(ctx, m.concurrency, len(tbls), func(ctx context.Context, i int) error {
+		cols, err := m.loadColumnDef(ctx, tbls[i].Name)
+		if err != nil {
+			return err
+		}
+		tbls[i].Columns = cols
+		if err := m.loadUniqueKeyDef(ctx, tbls[i]); err != nil {
+			return err
+		}
+		if err := m.loadIndexDef(ctx, tbls[i]); err != nil {
+			return err
+		}
+		defs[i], err = m.loadForeignKeyDef(ctx, tbls[i])
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	for i, tbl := range tbls {
+		if tbl.ForeingKeys, err = m.linkForeignKeys(tbls, tbl, defs[i]); err != nil {
+			return nil, err
+		}
+	}
+	return tbls, nil
+}
@@ -0,0 +1,222 @@
+package driver
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/maocatooo/planter/schema"
+)
+
+const testSQLiteSchema = `
+CREATE TABLE user (
+    user_id INTEGER PRIMARY KEY,
+    username TEXT NOT NULL,
+    email TEXT UNIQUE
+);
+CREATE TABLE product (
+    product_id INTEGER PRIMARY KEY,
+    product_name TEXT NOT NULL
+);
+CREATE TABLE cart (
+    cart_id INTEGER PRIMARY KEY,
+    user_id INTEGER NOT NULL REFERENCES user(user_id),
+    product_id INTEGER NOT NULL,
+    FOREIGN KEY (product_id) REFERENCES product
+);
+CREATE INDEX cart_user_idx ON cart (user_id, product_id) WHERE product_id > 0;
+CREATE TABLE cart_item (
+    cart_id INTEGER NOT NULL REFERENCES cart,
+    seq INTEGER NOT NULL,
+    PRIMARY KEY (cart_id, seq)
+);
+CREATE INDEX cart_item_seq_idx ON cart_item (seq)
+    where seq > 1;
+`
+
+func openTestSQLite(t *testing.T) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "test.db")
+	db, err := sql.Open("sqlite3", path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	if _, err := db.Exec(testSQLiteSchema); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func Test_sqlite_LoadTableDef(t *testing.T) {
+	p := NewSqlite(Options{})
+	if err := p.OpenDB(openTestSQLite(t)); err != nil {
+		t.Fatal(err)
+	}
+	tbls, err := p.LoadTableDef(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var names []string
+	for _, tbl := range tbls {
+		names = append(names, tbl.Name)
+	}
+	want := []string{"cart", "cart_item", "product", "user"}
+	if len(names) != len(want) {
+		t.Fatalf("tables = %v, want %v", names, want)
+	}
+	for i := range want {
+		if names[i] != want[i] {
+			t.Fatalf("tables = %v, want %v", names, want)
+		}
+	}
+
+	user, _ := schema.FindTableByName(tbls, "user")
+	if len(user.Columns) != 3 {
+		t.Fatalf("user columns = %d, want 3", len(user.Columns))
+	}
+	if c := user.Columns[0]; !c.IsPrimaryKey || c.FieldOrdinal != 1 {
+		t.Errorf("user_id = %+v, want primary key with ordinal 1", c)
+	}
+	if c := user.Columns[1]; !c.NotNull || c.DataType != "TEXT" || c.IsUnique {
+		t.Errorf("username = %+v, want NOT NULL TEXT", c)
+	}
+	if c := user.Columns[2]; !c.IsUnique || len(user.UniqueKeys) != 1 {
+		t.Errorf("email = %+v, want unique", c)
+	}
+
+	cart, _ := schema.FindTableByName(tbls, "cart")
+	if len(cart.Indexes) != 1 {
+		t.Fatalf("cart indexes = %+v", cart.Indexes)
+	}
+	if ix := cart.Indexes[0]; ix.Name != "cart_user_idx" || ix.Unique || len(ix.ColumnNames) != 2 || ix.Predicate != "product_id > 0" {
+		t.Errorf("cart index = %+v", ix)
+	}
+	if user, _ := schema.FindTableByName(tbls, "user"); len(user.Indexes) != 1 || !user.Indexes[0].Unique {
+		t.Errorf("user indexes = %+v", user.Indexes)
+	}
+	if len(cart.ForeingKeys) != 2 {
+		t.Fatalf("cart fks = %d, want 2", len(cart.ForeingKeys))
+	}
+	for _, fk := range cart.ForeingKeys {
+		if !fk.SourceColumn.IsForeignKey {
+			t.Errorf("%s.%s not marked as fk", fk.SourceTableName, fk.SourceColName)
+		}
+		if fk.TargetColName != fk.SourceColName {
+			t.Errorf("%s references %s.%s", fk.SourceColName, fk.TargetTableName, fk.TargetColName)
+		}
+		if fk.IsOneToOne() {
+			t.Errorf("%s should be one to many", fk.ConstraintName)
+		}
+	}
+
+	item, _ := schema.FindTableByName(tbls, "cart_item")
+	if !item.IsCompositePK() {
+		t.Error("cart_item should have a composite pk")
+	}
+	if len(item.ForeingKeys) != 1 || item.ForeingKeys[0].TargetColName != "cart_id" {
+		t.Fatalf("cart_item fks = %+v", item.ForeingKeys)
+	}
+	// WHERE on its own line
+	if len(item.Indexes) != 1 || item.Indexes[0].Predicate != "seq > 1" {
+		t.Errorf("cart_item indexes = %+v", item.Indexes)
+	}
+}
+
+func Test_sqlite_LoadTableDef_missingTarget(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "test.db")
+	db, err := sql.Open("sqlite3", path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	if _, err := db.Exec(`CREATE TABLE cart (cart_id INTEGER PRIMARY KEY, user_id INTEGER REFERENCES user(user_id))`); err != nil {
+		t.Fatal(err)
+	}
+
+	p := NewSqlite(Options{})
+	if err := p.OpenDB(path); err != nil {
+		t.Fatal(err)
+	}
+	_, err = p.LoadTableDef(context.Background())
+	if err == nil || !strings.Contains(err.Error(), "fk_cart_0: user not found") {
+		t.Fatalf("err = %v, want missing target table", err)
+	}
+}
+
+// cancelQueryer cancels the load after the first n queries
+type cancelQueryer struct {
+	*sql.DB
+	n      int
+	cancel context.CancelFunc
+}
+
+func (q *cancelQueryer) QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error) {
+	if q.n--; q.n < 0 {
+		q.cancel()
+	}
+	return q.DB.QueryContext(ctx, query, args...)
+}
+
+func Test_sqlite_LoadTableDef_canceled(t *testing.T) {
+	p := NewSqlite(Options{})
+	if err := p.OpenDB(openTestSQLite(t)); err != nil {
+		t.Fatal(err)
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	m := p.(*sqlite)
+	m.db = &cancelQueryer{DB: m.db.(*sql.DB), n: 1, cancel: cancel}
+
+	_, err := p.LoadTableDef(ctx)
+	if !errors.Is(err, context.Canceled) {
+		t.Fatalf("err = %v, want context canceled", err)
+	}
+	if !strings.Contains(err.Error(), "column def of cart") {
+		t.Errorf("err = %v, want the table being loaded", err)
+	}
+}
+
+func Test_sqlite_LoadTableDef_twice(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "test.db")
+	db, err := sql.Open("sqlite3", path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	if _, err := db.Exec(`
+CREATE TABLE line (order_id INTEGER, no INTEGER, PRIMARY KEY (order_id, no));
+CREATE TABLE note (id INTEGER PRIMARY KEY, order_id INTEGER, line_no INTEGER,
+    FOREIGN KEY (order_id, line_no) REFERENCES line);
+`); err != nil {
+		t.Fatal(err)
+	}
+
+	p := NewSqlite(Options{})
+	if err := p.OpenDB(path); err != nil {
+		t.Fatal(err)
+	}
+	// the primary key of line changes between the two loads
+	for i, want := range []string{"order_id,no", "no,order_id"} {
+		if i == 1 {
+			if _, err := db.Exec(`
+DROP TABLE line;
+CREATE TABLE line (order_id INTEGER, no INTEGER, PRIMARY KEY (no, order_id));
+`); err != nil {
+				t.Fatal(err)
+			}
+		}
+		tbls, err := p.LoadTableDef(context.Background())
+		if err != nil {
+			t.Fatalf("load %d: %v", i, err)
+		}
+		note, _ := schema.FindTableByName(tbls, "note")
+		if len(note.ForeingKeys) != 1 || strings.Join(note.ForeingKeys[0].TargetColNames(), ",") != want {
+			t.Errorf("load %d: note fks = %+v, want references %s", i, note.ForeingKeys, want)
+		}
+	}
+}
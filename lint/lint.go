@@ -0,0 +1,406 @@
+// Package lint checks loaded tables for common schema design problems
+package lint
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"unicode"
+
+	"github.com/maocatooo/planter/schema"
+	"github.com/pkg/errors"
+)
+
+// Severity how serious an issue is
+type Severity string
+
+// Severities of the rules
+const (
+	SeverityError   Severity = "error"
+	SeverityWarning Severity = "warning"
+)
+
+// Issue problem found by a rule, Column is empty for table level issues
+type Issue struct {
+	Rule     string   `json:"rule"`
+	Severity Severity `json:"severity"`
+	Table    string   `json:"table"`
+	Column   string   `json:"column,omitempty"`
+	Message  string   `json:"message"`
+}
+
+// Rule named check run over all tables
+type Rule struct {
+	Name        string
+	Severity    Severity
+	Description string
+	check       func(tbls []*schema.Table) []Issue
+}
+
+// Rules every rule, all enabled unless disabled by Config
+var Rules = []*Rule{
+	{
+		Name:        "no-primary-key",
+		Severity:    SeverityError,
+		Description: "table without primary key",
+		check:       noPrimaryKey,
+	},
+	{
+		Name:        "fk-without-index",
+		Severity:    SeverityWarning,
+		Description: "foreign key column not leading any index, deletes and joins on the referenced table scan it",
+		check:       fkWithoutIndex,
+	},
+	{
+		Name:        "fk-type-mismatch",
+		Severity:    SeverityError,
+		Description: "foreign key column type differs from the referenced column type",
+		check:       fkTypeMismatch,
+	},
+	{
+		Name:        "nullable-fk",
+		Severity:    SeverityWarning,
+		Description: "nullable foreign key column that looks mandatory: not a self reference, not unique and no name or comment marking it optional (parent_id, prev_id, \"optional\")",
+		check:       nullableFK,
+	},
+	{
+		Name:        "missing-table-comment",
+		Severity:    SeverityWarning,
+		Description: "table without comment",
+		check:       missingTableComment,
+	},
+	{
+		Name:        "missing-column-comment",
+		Severity:    SeverityWarning,
+		Description: "column without comment, primary keys are not checked",
+		check:       missingColumnComment,
+	},
+	{
+		Name:        "naming",
+		Severity:    SeverityWarning,
+		Description: "table or column name not following the naming style used by most names (snake_case, camelCase or PascalCase)",
+		check:       naming,
+	},
+}
+
+// Config enables or disables rules by name, rules not listed are enabled
+type Config struct {
+	Rules map[string]bool `json:"rules"`
+}
+
+// LoadConfig read a json config, e.g. {"rules": {"missing-column-comment": false}}
+func LoadConfig(path string) (Config, error) {
+	var cfg Config
+	bs, err := os.ReadFile(path)
+	if err != nil {
+		return cfg, errors.Wrap(err, "failed to read lint config")
+	}
+	if err := json.Unmarshal(bs, &cfg); err != nil {
+		return cfg, errors.Wrapf(err, "failed to parse lint config %s", path)
+	}
+	return cfg, nil
+}
+
+// Set enable or disable the named rule
+func (c *Config) Set(name string, enabled bool) {
+	if c.Rules == nil {
+		c.Rules = map[string]bool{}
+	}
+	c.Rules[name] = enabled
+}
+
+func findRule(name string) (*Rule, bool) {
+	for _, r := range Rules {
+		if r.Name == name {
+			return r, true
+		}
+	}
+	return nil, false
+}
+
+// Run check tbls with the rules enabled by cfg, issues are sorted by table,
+// column and rule
+func Run(tbls []*schema.Table, cfg Config) ([]Issue, error) {
+	for name := range cfg.Rules {
+		if _, found := findRule(name); !found {
+			return nil, errors.Errorf("unknown lint rule %s", name)
+		}
+	}
+	issues := []Issue{}
+	for _, r := range Rules {
+		if enabled, found := cfg.Rules[r.Name]; found && !enabled {
+			continue
+		}
+		for _, is := range r.check(tbls) {
+			is.Rule = r.Name
+			is.Severity = r.Severity
+			issues = append(issues, is)
+		}
+	}
+	sort.SliceStable(issues, func(i, j int) bool {
+		a, b := issues[i], issues[j]
+		if a.Table != b.Table {
+			return a.Table < b.Table
+		}
+		return a.Column < b.Column
+	})
+	return issues, nil
+}
+
+// foreignKeys every foreign key once, MySQL lists them on the referenced table
+func foreignKeys(tbls []*schema.Table) []*schema.ForeignKey {
+	var fks []*schema.ForeignKey
+	for _, tbl := range tbls {
+		fks = append(fks, tbl.ForeingKeys...)
+	}
+	return fks
+}
+
+func noPrimaryKey(tbls []*schema.Table) []Issue {
+	var issues []Issue
+	for _, tbl := range tbls {
+		hasPK := false
+		for _, c := range tbl.Columns {
+			hasPK = hasPK || c.IsPrimaryKey
+		}
+		if !hasPK {
+			issues = append(issues, Issue{Table: tbl.Name, Message: "table has no primary key"})
+		}
+	}
+	return issues
+}
+
+// leading names start with every one of cols, in any order
+func leading(names []string, cols []string) bool {
+	if len(names) < len(cols) {
+		return false
+	}
+	prefix := map[string]bool{}
+	for _, n := range names[:len(cols)] {
+		prefix[n] = true
+	}
+	for _, c := range cols {
+		if !prefix[c] {
+			return false
+		}
+	}
+	return true
+}
+
+// leadsIndex columns cols are the first keys of an index or of the primary key of tbl
+func leadsIndex(tbl *schema.Table, cols []string) bool {
+	var pk []string
+	for _, c := range tbl.Columns {
+		if c.IsPrimaryKey {
+			pk = append(pk, c.Name)
+		}
+	}
+	if leading(pk, cols) {
+		return true
+	}
+	for _, ix := range tbl.Indexes {
+		if leading(ix.ColumnNames, cols) {
+			return true
+		}
+	}
+	return false
+}
+
+func fkWithoutIndex(tbls []*schema.Table) []Issue {
+	var issues []Issue
+	for _, fk := range foreignKeys(tbls) {
+		cols := fk.SourceColNames()
+		if fk.SourceTable == nil || leadsIndex(fk.SourceTable, cols) {
+			continue
+		}
+		issues = append(issues, Issue{
+			Table:   fk.SourceTableName,
+			Column:  strings.Join(cols, ", "),
+			Message: fmt.Sprintf("foreign key %s has no index starting with %s", fk.ConstraintName, strings.Join(cols, ", ")),
+		})
+	}
+	return issues
+}
+
+func fkTypeMismatch(tbls []*schema.Table) []Issue {
+	var issues []Issue
+	for _, fk := range foreignKeys(tbls) {
+		for _, p := range fk.ColumnPairs() {
+			if p.SourceColumn == nil || p.TargetColumn == nil ||
+				strings.EqualFold(p.SourceColumn.DataType, p.TargetColumn.DataType) {
+				continue
+			}
+			issues = append(issues, Issue{
+				Table:  fk.SourceTableName,
+				Column: p.SourceColName,
+				Message: fmt.Sprintf("%s is %s but references %s.%s of type %s",
+					p.SourceColName, p.SourceColumn.DataType, fk.TargetTableName, p.TargetColName, p.TargetColumn.DataType),
+			})
+		}
+	}
+	return issues
+}
+
+// optionalWords words of column names and comments marking a reference that
+// is often missing: tree parents, chains and explicitly optional columns
+var optionalWords = []string{"parent", "prev", "previous", "next", "replaced", "merged", "referrer", "optional"}
+
+// looksOptional name or comment of c marks the reference as optional
+func looksOptional(c *schema.Column) bool {
+	name := strings.ToLower(c.Name)
+	comment := strings.ToLower(c.Comment.String)
+	for _, w := range optionalWords {
+		if strings.Contains(name, w) || strings.Contains(comment, w) {
+			return true
+		}
+	}
+	return false
+}
+
+// inUniqueKey c is unique or part of a unique key of tbl, an optional one to
+// one relation
+func inUniqueKey(tbl *schema.Table, c *schema.Column) bool {
+	if c.IsUnique {
+		return true
+	}
+	for _, uk := range tbl.UniqueKeys {
+		for _, name := range uk.ColumnNames {
+			if name == c.Name {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func nullableFK(tbls []*schema.Table) []Issue {
+	var issues []Issue
+	for _, fk := range foreignKeys(tbls) {
+		if fk.SourceTableName == fk.TargetTableName {
+			continue
+		}
+		for _, p := range fk.ColumnPairs() {
+			c := p.SourceColumn
+			if c == nil || c.NotNull || c.IsPrimaryKey || looksOptional(c) ||
+				(fk.SourceTable != nil && inUniqueKey(fk.SourceTable, c)) {
+				continue
+			}
+			issues = append(issues, Issue{
+				Table:   fk.SourceTableName,
+				Column:  p.SourceColName,
+				Message: fmt.Sprintf("%s references %s but is nullable, add NOT NULL if every row has one", p.SourceColName, fk.TargetTableName),
+			})
+		}
+	}
+	return issues
+}
+
+func blank(comment sql.NullString) bool {
+	return !comment.Valid || strings.TrimSpace(comment.String) == ""
+}
+
+func missingTableComment(tbls []*schema.Table) []Issue {
+	var issues []Issue
+	for _, tbl := range tbls {
+		if blank(tbl.Comment) {
+			issues = append(issues, Issue{Table: tbl.Name, Message: "table has no comment"})
+		}
+	}
+	return issues
+}
+
+func missingColumnComment(tbls []*schema.Table) []Issue {
+	var issues []Issue
+	for _, tbl := range tbls {
+		for _, c := range tbl.Columns {
+			if !c.IsPrimaryKey && blank(c.Comment) {
+				issues = append(issues, Issue{Table: tbl.Name, Column: c.Name, Message: "column has no comment"})
+			}
+		}
+	}
+	return issues
+}
+
+// naming styles, a single lower case word fits both snake_case and camelCase
+const (
+	styleSnake  = "snake_case"
+	styleCamel  = "camelCase"
+	stylePascal = "PascalCase"
+	styleLower  = "lower"
+	styleOther  = "Mixed_Case"
+)
+
+func namingStyle(name string) string {
+	var upper, lower, underscore bool
+	for _, r := range name {
+		switch {
+		case r == '_':
+			underscore = true
+		case unicode.IsUpper(r):
+			upper = true
+		case unicode.IsLower(r):
+			lower = true
+		}
+	}
+	first, _ := firstRune(name)
+	switch {
+	case underscore && !upper:
+		return styleSnake
+	case underscore:
+		return styleOther
+	case !upper:
+		return styleLower
+	case unicode.IsUpper(first) && lower:
+		return stylePascal
+	case unicode.IsLower(first):
+		return styleCamel
+	}
+	return styleOther
+}
+
+func firstRune(s string) (rune, bool) {
+	for _, r := range s {
+		return r, true
+	}
+	return 0, false
+}
+
+func fitsStyle(style, dominant string) bool {
+	return style == dominant || (style == styleLower && dominant != stylePascal)
+}
+
+func naming(tbls []*schema.Table) []Issue {
+	counts := map[string]int{}
+	count := func(name string) {
+		counts[namingStyle(name)]++
+	}
+	for _, tbl := range tbls {
+		count(tbl.Name)
+		for _, c := range tbl.Columns {
+			count(c.Name)
+		}
+	}
+	// single words do not tell the style apart, snake_case wins ties
+	dominant := styleSnake
+	for _, s := range []string{styleCamel, stylePascal} {
+		if counts[s] > counts[dominant] {
+			dominant = s
+		}
+	}
+
+	var issues []Issue
+	for _, tbl := range tbls {
+		if s := namingStyle(tbl.Name); !fitsStyle(s, dominant) {
+			issues = append(issues, Issue{Table: tbl.Name, Message: fmt.Sprintf("table name is %s, most names are %s", s, dominant)})
+		}
+		for _, c := range tbl.Columns {
+			if s := namingStyle(c.Name); !fitsStyle(s, dominant) {
+				issues = append(issues, Issue{Table: tbl.Name, Column: c.Name, Message: fmt.Sprintf("column name is %s, most names are %s", s, dominant)})
+			}
+		}
+	}
+	return issues
+}
@@ -0,0 +1,129 @@
+package lint
+
+import (
+	"testing"
+
+	"github.com/maocatooo/planter/driver"
+	"github.com/maocatooo/planter/schema"
+)
+
+func loadTestDDL(t *testing.T, src string) []*schema.Table {
+	t.Helper()
+	tbls, err := driver.ParseDDL([]byte(src))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return tbls
+}
+
+func issueKeys(issues []Issue) map[string]bool {
+	keys := map[string]bool{}
+	for _, is := range issues {
+		keys[is.Rule+" "+is.Table+"."+is.Column] = true
+	}
+	return keys
+}
+
+func Test_Run(t *testing.T) {
+	tbls := loadTestDDL(t, `
+CREATE TABLE user_account (
+  id int PRIMARY KEY,
+  display_name text COMMENT 'shown name',
+  manager_id int COMMENT 'boss' REFERENCES user_account(id)
+) COMMENT='users';
+CREATE TABLE orders (
+  id bigint PRIMARY KEY,
+  user_id bigint COMMENT 'owner' REFERENCES user_account(id),
+  createdAt timestamp COMMENT 'creation time',
+  note text
+) COMMENT='orders';
+CREATE INDEX orders_user_idx ON orders (user_id);
+CREATE TABLE audit_log (
+  message text COMMENT 'what happened',
+  order_id bigint NOT NULL COMMENT 'order' REFERENCES orders(id)
+);
+`)
+	issues, err := Run(tbls, Config{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := issueKeys(issues)
+	want := []string{
+		"no-primary-key audit_log.",
+		"missing-table-comment audit_log.",
+		"fk-without-index audit_log.order_id",
+		"fk-type-mismatch orders.user_id",
+		"nullable-fk orders.user_id",
+		"missing-column-comment orders.note",
+		"naming orders.createdAt",
+		"fk-without-index user_account.manager_id",
+	}
+	for _, k := range want {
+		if !got[k] {
+			t.Errorf("missing issue %s in %v", k, issues)
+		}
+	}
+	if len(issues) != len(want) {
+		t.Errorf("got %d issues, want %d: %v", len(issues), len(want), issues)
+	}
+	for i := 1; i < len(issues); i++ {
+		if issues[i-1].Table > issues[i].Table {
+			t.Errorf("issues are not sorted by table: %v", issues)
+		}
+	}
+
+	cfg := Config{}
+	cfg.Set("naming", false)
+	cfg.Set("missing-table-comment", false)
+	issues, err = Run(tbls, cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got = issueKeys(issues)
+	if got["naming orders.createdAt"] || got["missing-table-comment audit_log."] || len(issues) != len(want)-2 {
+		t.Errorf("disabled rules still reported: %v", issues)
+	}
+
+	cfg.Set("no-such-rule", true)
+	if _, err := Run(tbls, cfg); err == nil {
+		t.Error("expected an error for an unknown rule")
+	}
+}
+
+func Test_nullableFK(t *testing.T) {
+	tbls := loadTestDDL(t, `
+CREATE TABLE category (id int PRIMARY KEY);
+CREATE TABLE coupon (id int PRIMARY KEY);
+CREATE TABLE image (id int PRIMARY KEY);
+CREATE TABLE product (
+  id int PRIMARY KEY,
+  category_id int REFERENCES category(id),
+  parent_category_id int REFERENCES category(id),
+  coupon_id int COMMENT 'optional discount' REFERENCES coupon(id),
+  image_id int UNIQUE REFERENCES image(id)
+);
+`)
+	var got []string
+	for _, is := range nullableFK(tbls) {
+		got = append(got, is.Table+"."+is.Column)
+	}
+	if len(got) != 1 || got[0] != "product.category_id" {
+		t.Errorf("nullable-fk reported %v, want only product.category_id", got)
+	}
+}
+
+func Test_namingStyle(t *testing.T) {
+	for name, want := range map[string]string{
+		"user_id":   styleSnake,
+		"userId":    styleCamel,
+		"UserId":    stylePascal,
+		"user":      styleLower,
+		"User_Id":   styleOther,
+		"ID":        styleOther,
+		"order_2fa": styleSnake,
+	} {
+		if got := namingStyle(name); got != want {
+			t.Errorf("namingStyle(%s) = %s, want %s", name, got, want)
+		}
+	}
+}
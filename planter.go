@@ -1,292 +1,252 @@
-package main
+// Package planter generates ER diagrams from databases and schema files.
+//
+//	s, err := planter.Load(ctx, "postgres", dsn, planter.LoadOptions{Schema: "public"})
+//	if err != nil {
+//		return err
+//	}
+//	return planter.Render(s, planter.FormatMermaid, os.Stdout, planter.RenderOptions{})
+//
+// The model lives in package schema, the loaders in package driver and the
+// output formats in package render.
+package planter
 
 import (
-	"bytes"
-	"database/sql"
-	"fmt"
-	_ "github.com/go-sql-driver/mysql"
-	_ "github.com/lib/pq" // postgres
+	"context"
+	"io"
+
+	"github.com/maocatooo/planter/driver"
+	"github.com/maocatooo/planter/lint"
+	"github.com/maocatooo/planter/render"
+	"github.com/maocatooo/planter/schema"
 	"github.com/pkg/errors"
-	"html/template"
-	"regexp"
-	"sort"
-	"strings"
 )
 
-type Planter interface {
-	OpenDB(connStr string)
-	LoadTableDef() []*Table
-}
-
-// Queryer database/sql compatible query interface
-type Queryer interface {
-	Exec(string, ...interface{}) (sql.Result, error)
-	Query(string, ...interface{}) (*sql.Rows, error)
-	QueryRow(string, ...interface{}) *sql.Row
-}
-
-// Column postgres columns
-type Column struct {
-	FieldOrdinal int
-	Name         string
-	Comment      sql.NullString
-	DataType     string
-	DDLType      string
-	NotNull      bool
-	IsPrimaryKey bool
-	IsForeignKey bool
-}
-
-// ForeignKey foreign key
-type ForeignKey struct {
-	ConstraintName        string
-	SourceTableName       string
-	SourceColName         string
-	IsSourceColPrimaryKey bool
-	SourceTable           *Table
-	SourceColumn          *Column
-	TargetTableName       string
-	TargetColName         string
-	IsTargetColPrimaryKey bool
-	TargetTable           *Table
-	TargetColumn          *Column
-}
+// Format output format of Render
+type Format string
+
+// Formats supported by Render and RenderDiff
+const (
+	FormatPlantUML Format = "plantuml"
+	FormatMermaid  Format = "mermaid"
+	FormatDot      Format = "dot"
+	FormatJSON     Format = "json"
+	FormatSVG      Format = "svg"
+	// FormatText plain text report, RenderDiff and RenderLint only
+	FormatText Format = "text"
+)
 
-// IsOneToOne returns true if one to one relation
-// - in case of composite pk
-//   - one to one
-//   - source table is composite pk && target table is composite pk
-//   - source table fks to target table are all pks
-//   - other cases are one to many
-func (k *ForeignKey) IsOneToOne() bool {
-	switch {
-	case k.SourceTable.IsCompositePK() && k.TargetTable.IsCompositePK():
-		var targetFks []*ForeignKey
-		for _, fk := range k.SourceTable.ForeingKeys {
-			if fk.TargetTableName == k.TargetTableName {
-				targetFks = append(targetFks, fk)
-			}
-		}
-		for _, tfk := range targetFks {
-			if !tfk.IsSourceColPrimaryKey || !tfk.IsTargetColPrimaryKey {
-				return false
-			}
-		}
-		return true
-	case !k.SourceTable.IsCompositePK() && k.SourceColumn.IsPrimaryKey && k.TargetColumn.IsPrimaryKey:
-		return true
-	default:
-		return false
+// LoadOptions settings of Load
+type LoadOptions struct {
+	// Schema PostgreSQL schema name, Default public
+	Schema string
+	// Tables keep only the tables matching these patterns
+	Tables []string
+	// Exclude drop the tables matching these patterns
+	Exclude []string
+	// Match how Tables/Exclude patterns are matched, Default schema.MatchGlob
+	Match schema.MatchMode
+	// Infer foreign key inference from column names, see schema.InferForeignKeys
+	Infer schema.InferOptions
+	// Sample check the inferred foreign keys of the kept tables against the
+	// data when Sample.Rows is set, database drivers only, see
+	// driver.SampleForeignKeys
+	Sample driver.SampleOptions
+	// Focus keep only these tables and the tables within Depth foreign key
+	// hops of them, applied after Tables/Exclude
+	Focus []string
+	// Depth hops walked from Focus, 0 keeps only the Focus tables
+	Depth int
+	// Direction foreign keys walked from Focus, Default schema.DirectionBoth
+	Direction schema.Direction
+	// Concurrency number of connections used to query the tables selected by
+	// Tables/Exclude, the whole schema is loaded with bulk queries, Default 1
+	Concurrency int
+}
+
+// RenderOptions settings of Render and RenderDiff
+type RenderOptions struct {
+	// Title diagram title
+	Title string
+	// ShowIndexes list the indexes of every table in PlantUML, DOT and SVG output
+	ShowIndexes bool
+	// HideColumns column globs (created_at) or table.column globs
+	// (audit_*.payload) left out of the diagrams of Render, json and diff keep
+	// every column
+	HideColumns []string
+	// KeysOnly draw only the primary key and foreign key columns
+	KeysOnly bool
+	// CollapseJunctions draw many to many junction tables of Render as a
+	// relation between the two tables they reference, see schema.FindJunctions
+	CollapseJunctions bool
+	// SVGRenderer render FormatSVG from the PlantUML source instead of the
+	// built-in layout, see render.NewSVGRenderer
+	SVGRenderer render.SVGRenderer
+}
+
+// visibleTables tables drawn by the diagram formats
+func (o RenderOptions) visibleTables(tbls []*schema.Table) ([]*schema.Table, error) {
+	if o.CollapseJunctions {
+		tbls = schema.CollapseJunctions(tbls)
+	}
+	if len(o.HideColumns) == 0 && !o.KeysOnly {
+		return tbls, nil
+	}
+	hide, err := schema.ColumnFilter(o.HideColumns, o.KeysOnly)
+	if err != nil {
+		return nil, err
 	}
+	return schema.HideColumns(tbls, hide), nil
 }
 
-// Table postgres table
-type Table struct {
-	Name        string
-	Comment     sql.NullString
-	AutoGenPk   bool
-	Columns     []*Column
-	ForeingKeys []*ForeignKey
+func (o RenderOptions) diagram() render.Options {
+	return render.Options{Title: o.Title, ShowIndexes: o.ShowIndexes}
 }
 
-// IsCompositePK check if table is composite pk
-func (t *Table) IsCompositePK() bool {
-	cnt := 0
-	for _, c := range t.Columns {
-		if c.IsPrimaryKey {
-			cnt++
-		}
-		if cnt >= 2 {
-			return true
+// Load read the tables of dsn with the named driver
+// (mysql/postgres/sqlite/ddl/snapshot) and apply the table filters,
+// canceling ctx aborts the running catalog query
+func Load(ctx context.Context, driverName, dsn string, opts LoadOptions) (*schema.Schema, error) {
+	dopts := driver.Options{Schema: opts.Schema, Concurrency: opts.Concurrency}
+	if len(opts.Tables) != 0 || len(opts.Exclude) != 0 {
+		keep, err := schema.TableFilter(opts.Tables, opts.Exclude, opts.Match)
+		if err != nil {
+			return nil, err
 		}
+		dopts.Filter = keep
 	}
-	return false
-}
-
-func stripCommentSuffix(s string) string {
-	if tok := strings.SplitN(s, "\t", 2); len(tok) == 2 {
-		return tok[0]
+	p, err := driver.New(driverName, dopts)
+	if err != nil {
+		return nil, err
 	}
-	return s
-}
-
-// FindTableByName find table by name
-func FindTableByName(tbls []*Table, name string) (*Table, bool) {
-	for _, tbl := range tbls {
-		if tbl.Name == name {
-			return tbl, true
-		}
+	checker, canSample := p.(driver.InclusionChecker)
+	if opts.Sample.Rows > 0 && !canSample {
+		return nil, errors.Errorf("driver %s has no data to sample", driverName)
 	}
-	return nil, false
-}
-
-// FindColumnByName find table by name
-func FindColumnByName(tbls []*Table, tableName, colName string) (*Column, bool) {
-	for _, tbl := range tbls {
-		if tbl.Name == tableName {
-			for _, col := range tbl.Columns {
-				if col.Name == colName {
-					return col, true
-				}
-			}
-		}
+	if err := p.OpenDB(dsn); err != nil {
+		return nil, err
 	}
-	return nil, false
-}
-
-func contains(v string, r []*regexp.Regexp) bool {
-	for _, e := range r {
-		if e != nil && e.MatchString(v) {
-			return true
-		}
+	ts, err := p.LoadTableDef(ctx)
+	if err != nil {
+		return nil, err
 	}
-	return false
-}
 
-// FilterTables filter tables
-func FilterTables(match bool, tbls []*Table, tblNames []string) []*Table {
-	sort.Strings(tblNames)
+	schema.InferForeignKeys(ts, opts.Infer)
 
-	var tblExps []*regexp.Regexp
-	for _, tn := range tblNames {
-		str := fmt.Sprintf(`([\\/])?%s([\\/])?`, tn)
-		r := regexp.MustCompile(str)
-		tblExps = append(tblExps, r)
+	tbls := ts
+	if dopts.Filter != nil {
+		// drivers without catalog filtering (ddl, snapshot) load every table
+		tbls = schema.FilterTables(tbls, dopts.Filter)
 	}
-
-	var target []*Table
-	for _, tbl := range tbls {
-		if contains(tbl.Name, tblExps) == match {
-			var fks []*ForeignKey
-			for _, fk := range tbl.ForeingKeys {
-				if contains(fk.TargetTableName, tblExps) == match {
-					fks = append(fks, fk)
-				}
-			}
-			tbl.ForeingKeys = fks
-			target = append(target, tbl)
+	if opts.Sample.Rows > 0 {
+		sopts := opts.Sample
+		if sopts.Concurrency == 0 {
+			sopts.Concurrency = opts.Concurrency
+		}
+		if err := driver.SampleForeignKeys(ctx, checker, tbls, sopts); err != nil {
+			return nil, err
 		}
 	}
-	return target
-}
-
-// TableToUMLEntry table entry
-func TableToUMLEntry(tbls []*Table) ([]byte, error) {
-	tpl, err := template.New("entry").Parse(entryTmpl)
-	if err != nil {
-		return nil, err
-	}
-	var src []byte
-	for _, tbl := range tbls {
-		buf := new(bytes.Buffer)
-		if err := tpl.Execute(buf, tbl); err != nil {
-			return nil, errors.Wrapf(err, "failed to execute template: %s", tbl.Name)
+	if len(opts.Focus) != 0 {
+		if tbls, err = schema.FocusTables(tbls, opts.Focus, opts.Depth, opts.Direction); err != nil {
+			return nil, err
 		}
-		src = append(src, buf.Bytes()...)
 	}
-	return src, nil
+	return &schema.Schema{Tables: tbls}, nil
 }
 
-// ForeignKeyToUMLRelation relation
-func ForeignKeyToUMLRelation(tbls []*Table) ([]byte, error) {
-	tpl, err := template.New("relation").Parse(relationTmpl)
-	if err != nil {
-		return nil, err
+// Render write the diagram of s to w
+func Render(s *schema.Schema, format Format, w io.Writer, opts RenderOptions) error {
+	tbls := s.Tables
+	if format != FormatJSON {
+		var err error
+		if tbls, err = opts.visibleTables(tbls); err != nil {
+			return err
+		}
 	}
-	var src []byte
-	for _, tbl := range tbls {
-		for _, fk := range tbl.ForeingKeys {
-			buf := new(bytes.Buffer)
-			if err := tpl.Execute(buf, fk); err != nil {
-				return nil, errors.Wrapf(err, "failed to execute template: %s", fk.ConstraintName)
-			}
-			src = append(src, buf.Bytes()...)
+	var (
+		src []byte
+		err error
+	)
+	switch format {
+	case FormatPlantUML:
+		src, err = render.PlantUML(tbls, opts.diagram())
+	case FormatMermaid:
+		src, err = render.Mermaid(tbls, opts.diagram())
+	case FormatDot:
+		src, err = render.Dot(tbls, opts.diagram())
+	case FormatJSON:
+		src, err = render.JSON(s.Tables)
+	case FormatSVG:
+		if opts.SVGRenderer == nil {
+			src, err = render.SVG(tbls, opts.diagram())
+			break
 		}
+		src, err = render.PlantUML(tbls, opts.diagram())
+		if err == nil {
+			src, err = render.ExternalSVG(opts.SVGRenderer, string(src))
+		}
+	default:
+		return errors.Errorf("%s format is not supported", format)
 	}
-	return src, nil
-}
-
-func writePrefix(entry, rel []byte, title string) []byte {
-	var src []byte
-	src = append([]byte("@startuml\n"))
-	if len(title) != 0 {
-		src = append(src, []byte("title "+title+"\n")...)
+	if err != nil {
+		return err
 	}
-	src = append(src, []byte("hide circle\n"+
-		"skinparam linetype ortho\n")...)
-	src = append(src, entry...)
-	src = append(src, rel...)
-	src = append(src, []byte("@enduml\n")...)
-	return src
+	return write(w, src)
 }
 
-func (t *Table) fkEq(colName string) (*Column, bool) {
-	for _, col := range t.Columns {
-		if col.IsPrimaryKey {
-			if strings.Contains(col.Name, t.Name) {
-				if colName == col.Name {
-					return col, true
-				}
-			} else {
-				if colName == fmt.Sprintf("%s_%s", t.Name, col.Name) {
-					return col, true
-				}
-			}
-
-		}
-	}
-	return nil, false
+// Diff compare two schemas, from is the current state and to the desired one
+func Diff(from, to *schema.Schema) *schema.SchemaDiff {
+	return schema.DiffTables(from.Tables, to.Tables)
 }
 
-func (t *Table) analyzeFKRel(otherTab *Table) []*ForeignKey {
-	var fks []*ForeignKey
-	for _, col := range otherTab.Columns {
-		if col.IsPrimaryKey {
-			continue
+// RenderDiff write d as a text report (FormatText) or as a PlantUML diagram of
+// to highlighting the changes (FormatPlantUML, FormatSVG), SVG needs opts.SVGRenderer
+func RenderDiff(to *schema.Schema, d *schema.SchemaDiff, format Format, w io.Writer, opts RenderOptions) error {
+	if format == FormatText {
+		return write(w, render.DiffToText(d))
+	}
+	if format != FormatPlantUML && format != FormatSVG {
+		return errors.Errorf("%s format is not supported by diff", format)
+	}
+	src, err := render.DiffToUML(to.Tables, d, opts.diagram())
+	if err != nil {
+		return err
+	}
+	if format == FormatSVG {
+		// the native renderer has no notion of changes, render the PlantUML source
+		if opts.SVGRenderer == nil {
+			return render.ErrNoSVGRenderer
 		}
-		if source, ok := t.fkEq(col.Name); ok {
-			fks = append(fks, &ForeignKey{
-				ConstraintName:        col.Name,
-				SourceTableName:       t.Name,
-				SourceColName:         col.Name,
-				IsSourceColPrimaryKey: false,
-				SourceTable:           t,
-				SourceColumn:          source,
-				TargetTableName:       otherTab.Name,
-				TargetColName:         col.Name,
-				IsTargetColPrimaryKey: false,
-				TargetTable:           otherTab,
-				TargetColumn:          col,
-			})
+		if src, err = render.ExternalSVG(opts.SVGRenderer, string(src)); err != nil {
+			return err
 		}
 	}
-	return fks
+	return write(w, src)
 }
 
-// ForeignKeyAnalysis use foreign key analysis if all table not set fk
-func ForeignKeyAnalysis(tables []*Table) {
-	for _, item := range tables {
-		if len(item.ForeingKeys) > 0 {
-			return
-		}
-	}
+// Lint check s with the rules enabled by cfg, see lint.Rules
+func Lint(s *schema.Schema, cfg lint.Config) ([]lint.Issue, error) {
+	return lint.Run(s.Tables, cfg)
+}
 
-	for index, cul := range tables {
-		if index == len(tables)-1 {
-			break
-		}
-		for _, rel := range tables[index+1:] {
-			tabRelAnalysis(cul, rel)
+// RenderLint write issues as a text report (FormatText) or as json (FormatJSON)
+func RenderLint(issues []lint.Issue, format Format, w io.Writer) error {
+	switch format {
+	case FormatText:
+		return write(w, render.LintToText(issues))
+	case FormatJSON:
+		src, err := render.LintToJSON(issues)
+		if err != nil {
+			return err
 		}
+		return write(w, src)
 	}
+	return errors.Errorf("%s format is not supported by lint", format)
 }
 
-func tabRelAnalysis(cur, rel *Table) {
-	// cur has pk
-	fk1 := cur.analyzeFKRel(rel)
-	cur.ForeingKeys = append(cur.ForeingKeys, fk1...)
-	fk2 := rel.analyzeFKRel(cur)
-	// rel has pk
-	rel.ForeingKeys = append(rel.ForeingKeys, fk2...)
-
+func write(w io.Writer, src []byte) error {
+	if _, err := w.Write(src); err != nil {
+		return errors.Wrap(err, "failed to write output")
+	}
+	return nil
 }
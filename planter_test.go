@@ -0,0 +1,63 @@
+package planter
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/maocatooo/planter/driver"
+)
+
+func Test_LoadRender(t *testing.T) {
+	s, err := Load(context.Background(), "ddl", "test_mysql.sql", LoadOptions{Exclude: []string{"order_info"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(s.Tables) != 3 {
+		t.Fatalf("tables = %d, want 3", len(s.Tables))
+	}
+
+	for f, want := range map[Format]string{
+		FormatPlantUML: "@startuml",
+		FormatMermaid:  "erDiagram",
+		FormatDot:      "digraph",
+		FormatJSON:     `"version": 1`,
+		FormatSVG:      "<svg",
+	} {
+		var buf bytes.Buffer
+		if err := Render(s, f, &buf, RenderOptions{Title: "shop"}); err != nil {
+			t.Fatalf("%s: %v", f, err)
+		}
+		if !strings.Contains(buf.String(), want) {
+			t.Errorf("%s output misses %q", f, want)
+		}
+	}
+	var uml bytes.Buffer
+	if err := Render(s, FormatPlantUML, &uml, RenderOptions{}); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(uml.String(), `*""email"": //varchar(100) [UK] : 电子邮件//`) {
+		t.Errorf("unique column not marked:\n%s", uml.String())
+	}
+	var keys bytes.Buffer
+	if err := Render(s, FormatPlantUML, &keys, RenderOptions{KeysOnly: true}); err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(keys.String(), "email") || !strings.Contains(keys.String(), "product_id") {
+		t.Errorf("keys only output:\n%s", keys.String())
+	}
+	if err := Render(s, FormatPlantUML, new(bytes.Buffer), RenderOptions{HideColumns: []string{"["}}); err == nil {
+		t.Error("expected error for invalid column pattern")
+	}
+	if err := Render(s, FormatText, new(bytes.Buffer), RenderOptions{}); err == nil {
+		t.Error("expected error for text format")
+	}
+
+	if _, err := Load(context.Background(), "oracle", "x", LoadOptions{}); err == nil {
+		t.Error("expected unknown driver error")
+	}
+	if _, err := Load(context.Background(), "ddl", "test_mysql.sql", LoadOptions{Sample: driver.SampleOptions{Rows: 100}}); err == nil {
+		t.Error("expected error sampling a ddl file")
+	}
+}
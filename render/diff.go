@@ -0,0 +1,271 @@
+package render
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+	"text/template"
+
+	"github.com/maocatooo/planter/schema"
+	"github.com/pkg/errors"
+)
+
+func nullability(notNull bool) string {
+	if notNull {
+		return "NOT NULL"
+	}
+	return "NULL"
+}
+
+func columnSummary(c *schema.Column) string {
+	s := c.Name + " " + c.DataType + " " + nullability(c.NotNull)
+	if c.IsPrimaryKey {
+		s += " PK"
+	}
+	if c.IsUnique {
+		s += " UK"
+	}
+	return s
+}
+
+func fkSummary(fk *schema.ForeignKey) string {
+	target := strings.Join(fk.TargetColNames(), ", ")
+	if len(fk.ColumnPairs()) > 1 {
+		target = "(" + target + ")"
+	}
+	return fmt.Sprintf("%s (%s -> %s.%s)", fk.ConstraintName, strings.Join(fk.SourceColNames(), ", "), fk.TargetTableName, target)
+}
+
+func indexSummary(ix *schema.Index) string {
+	s := fmt.Sprintf("%s (%s)", ix.Name, strings.Join(ix.ColumnNames, ", "))
+	if ix.Unique {
+		s += " UNIQUE"
+	}
+	if ix.Method != "" {
+		s += " " + ix.Method
+	}
+	if ix.Predicate != "" {
+		s += " WHERE " + ix.Predicate
+	}
+	return s
+}
+
+// DiffToText plain text report, one line per change
+func DiffToText(d *schema.SchemaDiff) []byte {
+	buf := new(bytes.Buffer)
+	if d.Empty() {
+		buf.WriteString("no changes\n")
+		return buf.Bytes()
+	}
+	for _, t := range d.AddedTables {
+		fmt.Fprintf(buf, "+ table %s\n", t.Name)
+		for _, c := range t.Columns {
+			fmt.Fprintf(buf, "    + column %s\n", columnSummary(c))
+		}
+		for _, fk := range t.ForeingKeys {
+			fmt.Fprintf(buf, "    + fk %s\n", fkSummary(fk))
+		}
+		for _, ix := range t.Indexes {
+			fmt.Fprintf(buf, "    + index %s\n", indexSummary(ix))
+		}
+	}
+	for _, t := range d.RemovedTables {
+		fmt.Fprintf(buf, "- table %s\n", t.Name)
+	}
+	for _, td := range d.ChangedTables {
+		fmt.Fprintf(buf, "~ table %s\n", td.Name)
+		if td.CommentChanged {
+			fmt.Fprintf(buf, "    ~ comment %q -> %q\n", td.From.Comment.String, td.To.Comment.String)
+		}
+		for _, c := range td.AddedColumns {
+			fmt.Fprintf(buf, "    + column %s\n", columnSummary(c))
+		}
+		for _, c := range td.RemovedColumns {
+			fmt.Fprintf(buf, "    - column %s\n", c.Name)
+		}
+		for _, cd := range td.ChangedColumns {
+			fmt.Fprintf(buf, "    ~ column %s: %s\n", cd.Name, strings.Join(cd.Changes, ", "))
+		}
+		for _, fk := range td.AddedForeignKeys {
+			fmt.Fprintf(buf, "    + fk %s\n", fkSummary(fk))
+		}
+		for _, fk := range td.RemovedForeignKeys {
+			fmt.Fprintf(buf, "    - fk %s\n", fkSummary(fk))
+		}
+		for _, ix := range td.AddedIndexes {
+			fmt.Fprintf(buf, "    + index %s\n", indexSummary(ix))
+		}
+		for _, ix := range td.RemovedIndexes {
+			fmt.Fprintf(buf, "    - index %s\n", ix.Name)
+		}
+		for _, id := range td.ChangedIndexes {
+			fmt.Fprintf(buf, "    ~ index %s: %s\n", id.Name, strings.Join(id.Changes, ", "))
+		}
+	}
+	return buf.Bytes()
+}
+
+const (
+	diffAdded   = "added"
+	diffRemoved = "removed"
+	diffChanged = "changed"
+)
+
+// diffColumnView column drawn in the diff diagram
+type diffColumnView struct {
+	*schema.Column
+	Status string
+}
+
+// diffEntityView table drawn in the diff diagram
+type diffEntityView struct {
+	*schema.Table
+	Status  string
+	Columns []*diffColumnView
+}
+
+// diffRelationView foreign key drawn in the diff diagram
+type diffRelationView struct {
+	*schema.ForeignKey
+	Status string
+}
+
+// diffViews tables of to plus the removed ones, the tables of d list the
+// foreign keys on their source table while to may list them on the referenced
+// one, so changes are matched by table name and foreign key
+func diffViews(to []*schema.Table, d *schema.SchemaDiff) ([]*diffEntityView, []*diffRelationView) {
+	status := map[string]string{}
+	changed := map[string]*schema.TableDiff{}
+	fkStatus := map[*schema.ForeignKey]string{}
+	for _, t := range d.AddedTables {
+		status[t.Name] = diffAdded
+		for _, fk := range t.ForeingKeys {
+			fkStatus[fk] = diffAdded
+		}
+	}
+	for _, td := range d.ChangedTables {
+		status[td.Name] = diffChanged
+		changed[td.Name] = td
+		for _, fk := range td.AddedForeignKeys {
+			fkStatus[fk] = diffAdded
+		}
+	}
+
+	var (
+		entities  []*diffEntityView
+		relations []*diffRelationView
+	)
+	for _, t := range to {
+		e := &diffEntityView{Table: t, Status: status[t.Name]}
+		td := changed[t.Name]
+		colStatus := map[*schema.Column]string{}
+		if td != nil {
+			for _, c := range td.AddedColumns {
+				colStatus[c] = diffAdded
+			}
+			for _, cd := range td.ChangedColumns {
+				colStatus[cd.To] = diffChanged
+			}
+		}
+		for _, c := range t.Columns {
+			e.Columns = append(e.Columns, &diffColumnView{Column: c, Status: colStatus[c]})
+		}
+		if td != nil {
+			for _, c := range td.RemovedColumns {
+				e.Columns = append(e.Columns, &diffColumnView{Column: c, Status: diffRemoved})
+			}
+		}
+		entities = append(entities, e)
+		for _, fk := range t.ForeingKeys {
+			relations = append(relations, &diffRelationView{ForeignKey: fk, Status: fkStatus[fk]})
+		}
+		if td != nil {
+			for _, fk := range td.RemovedForeignKeys {
+				relations = append(relations, &diffRelationView{ForeignKey: fk, Status: diffRemoved})
+			}
+		}
+	}
+	for _, t := range d.RemovedTables {
+		e := &diffEntityView{Table: t, Status: diffRemoved}
+		for _, c := range t.Columns {
+			e.Columns = append(e.Columns, &diffColumnView{Column: c})
+		}
+		entities = append(entities, e)
+		for _, fk := range t.ForeingKeys {
+			relations = append(relations, &diffRelationView{ForeignKey: fk, Status: diffRemoved})
+		}
+	}
+	return entities, relations
+}
+
+var diffFuncs = template.FuncMap{
+	"sourceEnd": sourceEnd,
+	"targetEnd": targetEnd,
+	"diffBackground": func(status string) string {
+		switch status {
+		case diffAdded:
+			return "#palegreen "
+		case diffRemoved:
+			return "#pink "
+		case diffChanged:
+			return "#khaki "
+		}
+		return ""
+	},
+	"diffOpen": func(status string) string {
+		switch status {
+		case diffAdded:
+			return "<color:green>"
+		case diffRemoved:
+			return "<color:red>"
+		case diffChanged:
+			return "<color:darkorange>"
+		}
+		return ""
+	},
+	"diffClose": func(status string) string {
+		if status == "" {
+			return ""
+		}
+		return "</color>"
+	},
+	"diffLink": func(status string) string {
+		switch status {
+		case diffAdded:
+			return "[#green]"
+		case diffRemoved:
+			return "[#red,dashed]"
+		}
+		return "-"
+	},
+}
+
+// DiffToUML PlantUML diagram of the target schema plus removed objects,
+// added objects are green, removed red and changed orange
+func DiffToUML(to []*schema.Table, d *schema.SchemaDiff, opts Options) ([]byte, error) {
+	entityTpl, err := template.New("diffEntry").Funcs(diffFuncs).Parse(diffEntryTmpl)
+	if err != nil {
+		return nil, err
+	}
+	relationTpl, err := template.New("diffRelation").Funcs(diffFuncs).Parse(diffRelationTmpl)
+	if err != nil {
+		return nil, err
+	}
+	entities, relations := diffViews(to, d)
+	var entry, rel []byte
+	for _, e := range entities {
+		buf := new(bytes.Buffer)
+		if err := entityTpl.Execute(buf, e); err != nil {
+			return nil, errors.Wrapf(err, "failed to execute template: %s", e.Name)
+		}
+		entry = append(entry, buf.Bytes()...)
+	}
+	for _, r := range relations {
+		buf := new(bytes.Buffer)
+		if err := relationTpl.Execute(buf, r); err != nil {
+			return nil, errors.Wrapf(err, "failed to execute template: %s", r.ConstraintName)
+		}
+		rel = append(rel, buf.Bytes()...)
+	}
+	return writePrefix(entry, rel, opts.Title), nil
+}
@@ -0,0 +1,143 @@
+package render
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/maocatooo/planter/schema"
+)
+
+func Test_DiffTables(t *testing.T) {
+	from := loadTestDDL(t, `
+CREATE TABLE user (id int PRIMARY KEY, name varchar(50) NOT NULL, nick text);
+CREATE TABLE post (id int PRIMARY KEY, user_id int REFERENCES user(id), body text COMMENT 'body');
+CREATE TABLE legacy (id int PRIMARY KEY);
+`)
+	to := loadTestDDL(t, `
+CREATE TABLE user (id int PRIMARY KEY, name varchar(100), email text NOT NULL) COMMENT='users';
+CREATE TABLE post (id int PRIMARY KEY, user_id int, body text COMMENT 'body');
+CREATE TABLE tag (id int PRIMARY KEY, post_id int NOT NULL REFERENCES post(id));
+`)
+	d := schema.DiffTables(from, to)
+	want := `+ table tag
+    + column id int NOT NULL PK
+    + column post_id int NOT NULL
+    + fk tag_post_id_fkey (post_id -> post.id)
+- table legacy
+~ table post
+    - fk post_user_id_fkey (user_id -> user.id)
+~ table user
+    ~ comment "" -> "users"
+    + column email text NOT NULL
+    - column nick
+    ~ column name: type varchar(50) -> varchar(100), NOT NULL -> NULL
+`
+	if got := string(DiffToText(d)); got != want {
+		t.Errorf("got\n%s\nwant\n%s", got, want)
+	}
+
+	src, err := DiffToUML(to, d, Options{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, s := range []string{
+		`entity "**tag**" #palegreen {`,
+		`entity "**legacy**" #pink {`,
+		`<color:green>""email"": //text //</color>`,
+		`<color:red>""nick"": //text //</color>`,
+		`"**post**" }o-[#red,dashed]-o| "**user**"`,
+		`"**tag**" }o-[#green]-|| "**post**"`,
+	} {
+		if !strings.Contains(string(src), s) {
+			t.Errorf("missing %q in\n%s", s, src)
+		}
+	}
+
+	if d := schema.DiffTables(from, from); !d.Empty() || string(DiffToText(d)) != "no changes\n" {
+		t.Errorf("expected no changes, got %s", DiffToText(d))
+	}
+}
+
+// mysqlOwned list every foreign key of tbls on its referenced table like the
+// mysql driver does
+func mysqlOwned(tbls []*schema.Table) []*schema.Table {
+	var fks []*schema.ForeignKey
+	for _, tbl := range tbls {
+		fks = append(fks, tbl.ForeingKeys...)
+		tbl.ForeingKeys = nil
+	}
+	for _, fk := range fks {
+		fk.TargetTable.ForeingKeys = append(fk.TargetTable.ForeingKeys, fk)
+	}
+	return tbls
+}
+
+func Test_DiffTables_mysqlOwned(t *testing.T) {
+	const shop = `
+CREATE TABLE user (id int PRIMARY KEY);
+CREATE TABLE product (id int PRIMARY KEY);
+CREATE TABLE cart (id int PRIMARY KEY, user_id int NOT NULL REFERENCES user(id), product_id int NOT NULL REFERENCES product(id));
+`
+	mysql := mysqlOwned(loadTestDDL(t, shop))
+	if d := schema.DiffTables(mysql, loadTestDDL(t, shop)); !d.Empty() {
+		t.Errorf("same schema loaded by two drivers differs:\n%s", DiffToText(d))
+	}
+
+	to := loadTestDDL(t, shop+`
+CREATE TABLE review (id int PRIMARY KEY, product_id int NOT NULL REFERENCES product(id));
+`)
+	for _, tbl := range to {
+		if tbl.Name == "cart" {
+			tbl.ForeingKeys = tbl.ForeingKeys[:1]
+		}
+	}
+	d := schema.DiffTables(mysql, to)
+	want := `+ table review
+    + column id int NOT NULL PK
+    + column product_id int NOT NULL
+    + fk review_product_id_fkey (product_id -> product.id)
+~ table cart
+    - fk cart_product_id_fkey (product_id -> product.id)
+`
+	if got := string(DiffToText(d)); got != want {
+		t.Errorf("got\n%s\nwant\n%s", got, want)
+	}
+
+	src, err := DiffToUML(mysqlOwned(to), d, Options{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, s := range []string{
+		`"**review**" }o-[#green]-|| "**product**"`,
+		`"**cart**" }o-[#red,dashed]-|| "**product**"`,
+		`"**cart**" }o---|| "**user**"`,
+	} {
+		if !strings.Contains(string(src), s) {
+			t.Errorf("missing %q in\n%s", s, src)
+		}
+	}
+}
+
+func Test_DiffTables_defaultsAndIndexes(t *testing.T) {
+	from := loadTestDDL(t, `
+CREATE TABLE user (id int PRIMARY KEY, status varchar(10) NOT NULL DEFAULT 'active', email text, deleted_at timestamp);
+CREATE INDEX user_email ON user (email) WHERE deleted_at IS NULL;
+CREATE INDEX user_status ON user (status);
+`)
+	to := loadTestDDL(t, `
+CREATE TABLE user (id int AUTO_INCREMENT PRIMARY KEY, status varchar(10) NOT NULL DEFAULT 'new', email text, deleted_at timestamp);
+CREATE UNIQUE INDEX user_email ON user (email) WHERE deleted_at IS NULL AND email IS NOT NULL;
+CREATE INDEX user_deleted_at ON user (deleted_at);
+`)
+	d := schema.DiffTables(from, to)
+	want := `~ table user
+    ~ column id: extra "" -> "auto_increment"
+    ~ column status: default "active" -> "new"
+    + index user_deleted_at (deleted_at) btree
+    - index user_status
+    ~ index user_email: made unique, predicate "deleted_at IS NULL" -> "deleted_at IS NULL AND email IS NOT NULL"
+`
+	if got := string(DiffToText(d)); got != want {
+		t.Errorf("got\n%s\nwant\n%s", got, want)
+	}
+}
@@ -0,0 +1,140 @@
+package render
+
+import (
+	"bytes"
+	"fmt"
+	"html"
+	"strconv"
+	"strings"
+	"text/template"
+
+	"github.com/maocatooo/planter/schema"
+	"github.com/pkg/errors"
+)
+
+var dotFuncs = template.FuncMap{
+	"dotID":         dotID,
+	"dotEscape":     html.EscapeString,
+	"dotPort":       dotPort,
+	"dotEndpoint":   dotEndpoint,
+	"dotArrow":      dotArrow,
+	"relationLabel": relationLabel,
+	"dotMarkers":    dotMarkers,
+	"indexDef":      indexDef,
+}
+
+// dotID quoted graphviz identifier
+func dotID(s string) string {
+	return strconv.Quote(s)
+}
+
+// dotPort column port name, ordinals keep ports valid whatever the column is called
+func dotPort(c *schema.Column) string {
+	return fmt.Sprintf("c%d", c.FieldOrdinal)
+}
+
+// dotEndpoint edge end at the column port, at the node when the column is hidden
+func dotEndpoint(name string, t *schema.Table, c *schema.Column) string {
+	if t == nil {
+		return dotID(name) + ":" + dotPort(c)
+	}
+	for _, col := range t.Columns {
+		if col == c {
+			return dotID(name) + ":" + dotPort(c)
+		}
+	}
+	return dotID(name)
+}
+
+// dotArrow crow's foot arrow shape, the first shape is drawn next to the node
+func dotArrow(c schema.Cardinality) string {
+	switch c {
+	case schema.ExactlyOne:
+		return "teetee"
+	case schema.ZeroOrMany:
+		return "crowodot"
+	case schema.OneOrMany:
+		return "crowtee"
+	}
+	return "teeodot"
+}
+
+func dotMarkers(c *schema.Column) string {
+	var markers []string
+	if c.IsPrimaryKey {
+		markers = append(markers, "PK")
+	}
+	if c.IsForeignKey {
+		markers = append(markers, "FK")
+	}
+	if c.IsUnique && !c.IsPrimaryKey {
+		markers = append(markers, "UK")
+	}
+	if c.NotNull && !c.IsPrimaryKey {
+		markers = append(markers, "NOT NULL")
+	}
+	return strings.Join(markers, " ")
+}
+
+// TableToDotNode table node with one row per column, and per index with opts.ShowIndexes
+func TableToDotNode(tbls []*schema.Table, opts Options) ([]byte, error) {
+	tpl, err := template.New("dotEntry").Funcs(dotFuncs).Parse(dotEntryTmpl)
+	if err != nil {
+		return nil, err
+	}
+	var src []byte
+	for _, tbl := range tbls {
+		buf := new(bytes.Buffer)
+		if err := tpl.Execute(buf, entryView{Table: tbl, ShowIndexes: opts.ShowIndexes}); err != nil {
+			return nil, errors.Wrapf(err, "failed to execute template: %s", tbl.Name)
+		}
+		src = append(src, buf.Bytes()...)
+	}
+	return src, nil
+}
+
+// ForeignKeyToDotEdge edge from the source column port to the target column port
+func ForeignKeyToDotEdge(tbls []*schema.Table) ([]byte, error) {
+	tpl, err := template.New("dotRelation").Funcs(dotFuncs).Parse(dotRelationTmpl)
+	if err != nil {
+		return nil, err
+	}
+	var src []byte
+	for _, tbl := range tbls {
+		for _, fk := range tbl.ForeingKeys {
+			buf := new(bytes.Buffer)
+			if err := tpl.Execute(buf, fk); err != nil {
+				return nil, errors.Wrapf(err, "failed to execute template: %s", fk.ConstraintName)
+			}
+			src = append(src, buf.Bytes()...)
+		}
+	}
+	return src, nil
+}
+
+func writeDotPrefix(entry, rel []byte, title string) []byte {
+	src := []byte("digraph planter {\n")
+	src = append(src, []byte("  graph [rankdir=LR];\n")...)
+	if len(title) != 0 {
+		src = append(src, []byte("  graph [label="+dotID(title)+", labelloc=t];\n")...)
+	}
+	src = append(src, []byte("  node [shape=plain];\n"+
+		"  edge [dir=both];\n")...)
+	src = append(src, entry...)
+	src = append(src, rel...)
+	src = append(src, []byte("}\n")...)
+	return src
+}
+
+// Dot Graphviz DOT source of the tables
+func Dot(tbls []*schema.Table, opts Options) ([]byte, error) {
+	entry, err := TableToDotNode(tbls, opts)
+	if err != nil {
+		return nil, err
+	}
+	rel, err := ForeignKeyToDotEdge(tbls)
+	if err != nil {
+		return nil, err
+	}
+	return writeDotPrefix(entry, rel, opts.Title), nil
+}
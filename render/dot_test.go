@@ -0,0 +1,46 @@
+package render
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/maocatooo/planter/driver"
+	"github.com/maocatooo/planter/schema"
+)
+
+func Test_Dot(t *testing.T) {
+	tbls, err := driver.ParseDDL([]byte(`
+CREATE TABLE a (id int PRIMARY KEY, name varchar(10) NOT NULL) COMMENT='<a & b>';
+CREATE TABLE b (id int PRIMARY KEY, a_id int REFERENCES a(id));
+`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	out, err := Dot(tbls, Options{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{
+		`<tr><td colspan="3"><i>&lt;a &amp; b&gt;</i></td></tr>`,
+		`<tr><td port="c1" align="left"><u>id</u></td><td align="left">int</td><td align="left">PK</td></tr>`,
+		`<tr><td port="c2" align="left">name</td><td align="left">varchar(10)</td><td align="left">NOT NULL</td></tr>`,
+		`<tr><td port="c2" align="left">a_id</td><td align="left">int</td><td align="left">FK</td></tr>`,
+		`"b":c2 -> "a":c1 [arrowtail=crowodot, arrowhead=teeodot, tooltip="b_a_id_fkey"];`,
+	} {
+		if !strings.Contains(string(out), want) {
+			t.Errorf("missing %q in\n%s", want, out)
+		}
+	}
+
+	// a hidden foreign key column moves the edge end to the node
+	hidden := *tbls[1]
+	hidden.Columns = hidden.Columns[:1]
+	hidden.ForeingKeys[0].SourceTable = &hidden
+	out, err = Dot([]*schema.Table{tbls[0], &hidden}, Options{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := `"b" -> "a":c1 [`; !strings.Contains(string(out), want) {
+		t.Errorf("missing %q in\n%s", want, out)
+	}
+}
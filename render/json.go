@@ -0,0 +1,17 @@
+package render
+
+import (
+	"encoding/json"
+
+	"github.com/maocatooo/planter/schema"
+	"github.com/pkg/errors"
+)
+
+// JSON snapshot of the tables, can be loaded back with the snapshot driver
+func JSON(tbls []*schema.Table) ([]byte, error) {
+	bs, err := json.MarshalIndent(schema.TablesToSnapshot(tbls), "", "  ")
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to encode snapshot")
+	}
+	return append(bs, '\n'), nil
+}
@@ -0,0 +1,49 @@
+package render
+
+import (
+	"encoding/json"
+	"os"
+	"testing"
+
+	"github.com/maocatooo/planter/schema"
+)
+
+func Test_JSON_roundTrip(t *testing.T) {
+	src, err := os.ReadFile("../test_mysql.sql")
+	if err != nil {
+		t.Fatal(err)
+	}
+	tbls := loadTestDDL(t, string(src))
+	bs, err := JSON(tbls)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var s schema.Snapshot
+	if err := json.Unmarshal(bs, &s); err != nil {
+		t.Fatal(err)
+	}
+	got, err := s.ToTables()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if d := schema.DiffTables(tbls, got); !d.Empty() {
+		t.Errorf("snapshot changed the schema:\n%s", DiffToText(d))
+	}
+
+	cart, _ := schema.FindTableByName(got, "cart")
+	user, _ := schema.FindTableByName(got, "user")
+	fk := cart.ForeingKeys[0]
+	if fk.SourceTable != cart || fk.TargetTable != user || fk.TargetColumn != user.Columns[0] || fk.SourceColumn != cart.Columns[1] {
+		t.Errorf("foreign key is not linked: %+v", fk)
+	}
+	if !cart.Comment.Valid || cart.Comment.String != "购物车" {
+		t.Errorf("cart comment = %+v", cart.Comment)
+	}
+	if c := cart.Columns[4]; c.Comment.String != "添加时间" || c.NotNull || c.Default.String != "CURRENT_TIMESTAMP" {
+		t.Errorf("added_at = %+v", c)
+	}
+	if c := cart.Columns[0]; c.Extra != "auto_increment" || c.Default.Valid {
+		t.Errorf("cart_id = %+v", c)
+	}
+}
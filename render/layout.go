@@ -0,0 +1,473 @@
+package render
+
+import (
+	"sort"
+	"strings"
+	"unicode"
+
+	"github.com/maocatooo/planter/schema"
+)
+
+// layout metrics in px, text is drawn with a monospace font so widths can be
+// computed from rune counts
+const (
+	layoutCharWidth   = 8
+	layoutRowHeight   = 20
+	layoutHeaderH     = 26
+	layoutPadding     = 8
+	layoutColumnGap   = 16
+	layoutNodeGap     = 40 // vertical gap between tables of a layer
+	layoutLayerGap    = 80 // minimal width of the channel between layers
+	layoutLaneGap     = 10 // distance between parallel edge segments
+	layoutMarkerSpace = 20 // room for crow's foot markers next to a table
+	layoutMargin      = 20
+	layoutTitleH      = 30
+	layoutSweeps      = 4
+)
+
+type point struct {
+	X, Y int
+}
+
+// layoutNode a table box
+type layoutNode struct {
+	Table *schema.Table
+	// Rows columns in drawing order, primary keys first
+	Rows   []*schema.Column
+	PKRows int
+	// Indexes drawn below the columns with Options.ShowIndexes
+	Indexes    []*schema.Index
+	X, Y, W, H int
+	NameW      int
+	TypeW      int
+	Layer      int
+	order      float64
+	neighbours []*layoutNode
+}
+
+// layoutEdge a foreign key routed as an orthogonal polyline from source to target
+type layoutEdge struct {
+	FK     *schema.ForeignKey
+	From   *layoutNode
+	To     *layoutNode
+	Points []point
+
+	fromRight bool
+	toRight   bool
+	fromCh    int
+	toCh      int
+	fromLane  int
+	toLane    int
+	topLane   int
+	// lane coordinates once the tables are placed
+	fromX int
+	toX   int
+	topY  int
+}
+
+type diagramLayout struct {
+	Title  string
+	Nodes  []*layoutNode
+	Edges  []*layoutEdge
+	Width  int
+	Height int
+}
+
+// textWidth width of s in px, east asian wide characters take two cells
+func textWidth(s string) int {
+	n := 0
+	for _, r := range s {
+		if isWideRune(r) {
+			n += 2
+		} else {
+			n++
+		}
+	}
+	return n * layoutCharWidth
+}
+
+func isWideRune(r rune) bool {
+	return unicode.In(r, unicode.Han, unicode.Hangul, unicode.Hiragana, unicode.Katakana) ||
+		(r >= 0xff00 && r <= 0xff60) || (r >= 0x3000 && r <= 0x303f)
+}
+
+func columnMarkers(c *schema.Column) string {
+	var markers []string
+	if c.IsPrimaryKey {
+		markers = append(markers, "PK")
+	}
+	if c.IsForeignKey {
+		markers = append(markers, "FK")
+	}
+	if c.IsUnique && !c.IsPrimaryKey {
+		markers = append(markers, "UK")
+	}
+	return strings.Join(markers, " ")
+}
+
+// columnLabel column name, required columns are prefixed with * like in the PlantUML entity
+func columnLabel(c *schema.Column) string {
+	if c.NotNull && !c.IsPrimaryKey {
+		return "*" + c.Name
+	}
+	return c.Name
+}
+
+func newLayoutNode(t *schema.Table, showIndexes bool) *layoutNode {
+	n := &layoutNode{Table: t}
+	if showIndexes {
+		n.Indexes = t.Indexes
+	}
+	for _, c := range t.Columns {
+		if c.IsPrimaryKey {
+			n.Rows = append(n.Rows, c)
+		}
+	}
+	n.PKRows = len(n.Rows)
+	for _, c := range t.Columns {
+		if !c.IsPrimaryKey {
+			n.Rows = append(n.Rows, c)
+		}
+	}
+	markW := 0
+	for _, c := range n.Rows {
+		n.NameW = max(n.NameW, textWidth(columnLabel(c)))
+		n.TypeW = max(n.TypeW, textWidth(c.DataType))
+		markW = max(markW, textWidth(columnMarkers(c)))
+	}
+	defW := 0
+	for _, ix := range n.Indexes {
+		n.NameW = max(n.NameW, textWidth(ix.Name))
+		defW = max(defW, textWidth(indexDef(ix)))
+	}
+	n.W = layoutPadding*2 + n.NameW + layoutColumnGap + n.TypeW + layoutColumnGap + markW
+	n.W = max(n.W, layoutPadding*2+n.NameW+layoutColumnGap+defW)
+	// bold header text is a bit wider
+	n.W = max(n.W, layoutPadding*2+textWidth(t.Name)*11/10)
+	n.H = layoutHeaderH + (len(n.Rows)+len(n.Indexes))*layoutRowHeight + layoutPadding/2
+	if t.Comment.Valid && t.Comment.String != "" {
+		n.W = max(n.W, layoutPadding*2+textWidth(t.Comment.String))
+		n.H += layoutRowHeight
+	}
+	return n
+}
+
+// bodyTop y offset of the first column row inside the node
+func (n *layoutNode) bodyTop() int {
+	if n.Table.Comment.Valid && n.Table.Comment.String != "" {
+		return layoutHeaderH + layoutRowHeight
+	}
+	return layoutHeaderH
+}
+
+// portY vertical center of the row of column c, the header when c is not drawn
+func (n *layoutNode) portY(c *schema.Column) int {
+	for i, r := range n.Rows {
+		if r == c {
+			return n.Y + n.bodyTop() + i*layoutRowHeight + layoutRowHeight/2
+		}
+	}
+	return n.Y + layoutHeaderH/2
+}
+
+func max(a, b int) int {
+	if a > b {
+		return a
+	}
+	return b
+}
+
+// layoutTables place tables in layers along the foreign key graph, referenced
+// tables to the left of the tables referencing them, and route every foreign key.
+// Tables without relations are laid out in a grid below the graph.
+// The result only depends on the order of tbls.
+func layoutTables(tbls []*schema.Table, opts Options) *diagramLayout {
+	l := &diagramLayout{Title: opts.Title}
+	byTable := map[*schema.Table]*layoutNode{}
+	for _, t := range tbls {
+		n := newLayoutNode(t, opts.ShowIndexes)
+		byTable[t] = n
+		l.Nodes = append(l.Nodes, n)
+	}
+	for _, t := range tbls {
+		for _, fk := range t.ForeingKeys {
+			// MySQL lists foreign keys on the referenced table, use both ends
+			from, to := byTable[fk.SourceTable], byTable[fk.TargetTable]
+			if from == nil || to == nil {
+				continue
+			}
+			l.Edges = append(l.Edges, &layoutEdge{FK: fk, From: from, To: to})
+			// self references keep the table in the graph so the loop has a channel
+			from.neighbours = append(from.neighbours, to)
+			if from != to {
+				to.neighbours = append(to.neighbours, from)
+			}
+		}
+	}
+
+	var connected, isolated []*layoutNode
+	for _, n := range l.Nodes {
+		if len(n.neighbours) > 0 {
+			connected = append(connected, n)
+		} else {
+			isolated = append(isolated, n)
+		}
+	}
+	layers := assignLayers(connected, l.Edges)
+	orderLayers(layers)
+	l.routeChannels()
+	l.place(layers, isolated)
+	for _, e := range l.Edges {
+		l.route(e)
+	}
+	return l
+}
+
+// assignLayers longest path layering, a table is placed one layer right of
+// the right-most table it references. Back edges of cycles are ignored.
+func assignLayers(nodes []*layoutNode, edges []*layoutEdge) [][]*layoutNode {
+	targets := map[*layoutNode][]*layoutNode{}
+	for _, e := range edges {
+		if e.From != e.To {
+			targets[e.From] = append(targets[e.From], e.To)
+		}
+	}
+	const (
+		visiting = iota + 1
+		done
+	)
+	state := map[*layoutNode]int{}
+	var visit func(n *layoutNode) int
+	visit = func(n *layoutNode) int {
+		switch state[n] {
+		case visiting:
+			return -1
+		case done:
+			return n.Layer
+		}
+		state[n] = visiting
+		layer := 0
+		for _, t := range targets[n] {
+			if tl := visit(t); tl >= 0 {
+				layer = max(layer, tl+1)
+			}
+		}
+		n.Layer = layer
+		state[n] = done
+		return layer
+	}
+	var layers [][]*layoutNode
+	for _, n := range nodes {
+		visit(n)
+	}
+	for _, n := range nodes {
+		for len(layers) <= n.Layer {
+			layers = append(layers, nil)
+		}
+		layers[n.Layer] = append(layers[n.Layer], n)
+	}
+	return layers
+}
+
+// orderLayers reduce crossings with barycenter sweeps over the layers
+func orderLayers(layers [][]*layoutNode) {
+	renumber := func(layer []*layoutNode) {
+		for i, n := range layer {
+			n.order = float64(i)
+		}
+	}
+	for _, layer := range layers {
+		renumber(layer)
+	}
+	sweep := func(layer []*layoutNode, adjacent int) {
+		bary := map[*layoutNode]float64{}
+		for _, n := range layer {
+			sum, cnt := 0.0, 0
+			for _, o := range n.neighbours {
+				if o.Layer == adjacent {
+					sum += o.order
+					cnt++
+				}
+			}
+			if cnt == 0 {
+				bary[n] = n.order
+			} else {
+				bary[n] = sum / float64(cnt)
+			}
+		}
+		sort.SliceStable(layer, func(i, j int) bool {
+			return bary[layer[i]] < bary[layer[j]]
+		})
+		renumber(layer)
+	}
+	for i := 0; i < layoutSweeps; i++ {
+		for li := 1; li < len(layers); li++ {
+			sweep(layers[li], li-1)
+		}
+		for li := len(layers) - 2; li >= 0; li-- {
+			sweep(layers[li], li+1)
+		}
+	}
+}
+
+// routeChannels decide which side of the tables each edge uses and reserve lanes.
+// Channel i is the gap right of layer i, channel -1 is left of layer 0.
+// Edges between adjacent layers or inside a layer use a single channel, longer
+// edges leave through the channel next to the source, run above all tables and
+// come down in the channel next to the target.
+func (l *diagramLayout) routeChannels() {
+	for _, e := range l.Edges {
+		fl, tl := e.From.Layer, e.To.Layer
+		switch {
+		case fl > tl:
+			e.fromRight, e.toRight = false, true
+			e.fromCh, e.toCh = fl-1, tl
+		case fl < tl:
+			e.fromRight, e.toRight = true, false
+			e.fromCh, e.toCh = fl, tl-1
+		default:
+			e.fromRight, e.toRight = true, true
+			e.fromCh, e.toCh = fl, tl
+		}
+	}
+}
+
+func (l *diagramLayout) place(layers [][]*layoutNode, isolated []*layoutNode) {
+	lanes := map[int]int{}
+	topLanes := 0
+	for _, e := range l.Edges {
+		e.fromLane = lanes[e.fromCh]
+		lanes[e.fromCh]++
+		if e.fromCh != e.toCh {
+			e.toLane = lanes[e.toCh]
+			lanes[e.toCh]++
+			e.topLane = topLanes
+			topLanes++
+		} else {
+			e.toLane = e.fromLane
+		}
+	}
+	channelW := func(ch int) int {
+		w := 0
+		if lanes[ch] > 0 {
+			w = layoutMarkerSpace*2 + (lanes[ch]-1)*layoutLaneGap
+		}
+		if ch >= 0 && ch < len(layers)-1 {
+			w = max(w, layoutLayerGap)
+		}
+		return w
+	}
+
+	top := layoutMargin
+	if l.Title != "" {
+		top += layoutTitleH
+	}
+	if topLanes > 0 {
+		top += topLanes*layoutLaneGap + layoutLaneGap
+	}
+
+	channelX := map[int]int{}
+	x := layoutMargin
+	channelX[-1] = x
+	x += channelW(-1)
+	height := 0
+	layerH := make([]int, len(layers))
+	for i, layer := range layers {
+		w := 0
+		for _, n := range layer {
+			w = max(w, n.W)
+			layerH[i] += n.H + layoutNodeGap
+		}
+		layerH[i] -= layoutNodeGap
+		height = max(height, layerH[i])
+		for _, n := range layer {
+			n.X = x
+		}
+		x += w
+		channelX[i] = x
+		x += channelW(i)
+	}
+	for i, layer := range layers {
+		y := top + (height-layerH[i])/2
+		for _, n := range layer {
+			n.Y = y
+			y += n.H + layoutNodeGap
+		}
+	}
+
+	laneX := func(ch, lane int) int {
+		return channelX[ch] + (channelW(ch)-(lanes[ch]-1)*layoutLaneGap)/2 + lane*layoutLaneGap
+	}
+	for _, e := range l.Edges {
+		e.fromX = laneX(e.fromCh, e.fromLane)
+		e.toX = laneX(e.toCh, e.toLane)
+		e.topY = top - layoutLaneGap - e.topLane*layoutLaneGap
+	}
+
+	l.Width = x
+	l.Height = top
+	if len(layers) > 0 {
+		l.Height += height + layoutNodeGap
+	}
+	// grid of unrelated tables, wrapped at the width of the graph
+	wrap := max(l.Width, 1000)
+	gx, gy, rowH := layoutMargin, l.Height, 0
+	for _, n := range isolated {
+		if gx > layoutMargin && gx+n.W > wrap {
+			gx = layoutMargin
+			gy += rowH + layoutNodeGap
+			rowH = 0
+		}
+		n.X, n.Y = gx, gy
+		gx += n.W + layoutNodeGap
+		rowH = max(rowH, n.H)
+		l.Width = max(l.Width, n.X+n.W)
+	}
+	if len(isolated) > 0 {
+		l.Height = gy + rowH + layoutNodeGap
+	}
+	l.Width += layoutMargin
+	l.Height += layoutMargin - layoutNodeGap
+}
+
+// route compute the polyline of an edge
+func (l *diagramLayout) route(e *layoutEdge) {
+	sx := e.From.X
+	if e.fromRight {
+		sx += e.From.W
+	}
+	tx := e.To.X
+	if e.toRight {
+		tx += e.To.W
+	}
+	sy := e.From.portY(e.FK.SourceColumn)
+	ty := e.To.portY(e.FK.TargetColumn)
+	if e.fromCh == e.toCh {
+		e.Points = simplifyPath([]point{{sx, sy}, {e.fromX, sy}, {e.fromX, ty}, {tx, ty}})
+		return
+	}
+	e.Points = simplifyPath([]point{
+		{sx, sy}, {e.fromX, sy}, {e.fromX, e.topY},
+		{e.toX, e.topY}, {e.toX, ty}, {tx, ty},
+	})
+}
+
+// simplifyPath drop points in the middle of straight segments
+func simplifyPath(pts []point) []point {
+	out := []point{pts[0]}
+	for i := 1; i < len(pts); i++ {
+		p := pts[i]
+		if p == out[len(out)-1] {
+			continue
+		}
+		if len(out) >= 2 {
+			a, b := out[len(out)-2], out[len(out)-1]
+			if (a.X == b.X && b.X == p.X) || (a.Y == b.Y && b.Y == p.Y) {
+				out[len(out)-1] = p
+				continue
+			}
+		}
+		out = append(out, p)
+	}
+	return out
+}
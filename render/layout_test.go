@@ -0,0 +1,103 @@
+package render
+
+import (
+	"bytes"
+	"flag"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/maocatooo/planter/driver"
+	"github.com/maocatooo/planter/schema"
+)
+
+var update = flag.Bool("update", false, "update golden files")
+
+func loadTestDDL(t *testing.T, src string) []*schema.Table {
+	t.Helper()
+	tbls, err := driver.ParseDDL([]byte(src))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return tbls
+}
+
+// crosses reports whether the segment a-b passes through the inside of n
+func crosses(a, b point, n *layoutNode) bool {
+	minX, maxX := a.X, b.X
+	if minX > maxX {
+		minX, maxX = maxX, minX
+	}
+	minY, maxY := a.Y, b.Y
+	if minY > maxY {
+		minY, maxY = maxY, minY
+	}
+	return minX < n.X+n.W && maxX > n.X && minY < n.Y+n.H && maxY > n.Y
+}
+
+func Test_layoutTables(t *testing.T) {
+	tbls := loadTestDDL(t, `
+CREATE TABLE country (id int PRIMARY KEY, name text);
+CREATE TABLE city (id int PRIMARY KEY, country_id int NOT NULL REFERENCES country(id));
+CREATE TABLE address (id int PRIMARY KEY, city_id int REFERENCES city(id), country_id int REFERENCES country(id));
+CREATE TABLE employee (id int PRIMARY KEY, manager_id int REFERENCES employee(id), address_id int REFERENCES address(id));
+CREATE TABLE team (id int PRIMARY KEY, lead_id int REFERENCES member(id));
+CREATE TABLE member (id int PRIMARY KEY, team_id int REFERENCES team(id));
+CREATE TABLE setting (k text PRIMARY KEY, v text);
+`)
+	l := layoutTables(tbls, Options{Title: "t"})
+	layer := map[string]int{}
+	for _, n := range l.Nodes {
+		layer[n.Table.Name] = n.Layer
+		if n.X < 0 || n.Y < 0 || n.X+n.W > l.Width || n.Y+n.H > l.Height {
+			t.Errorf("%s is outside of the canvas", n.Table.Name)
+		}
+	}
+	if !(layer["country"] < layer["city"] && layer["city"] < layer["address"] && layer["address"] < layer["employee"]) {
+		t.Errorf("unexpected layers %v", layer)
+	}
+	for _, e := range l.Edges {
+		for i := 1; i < len(e.Points); i++ {
+			a, b := e.Points[i-1], e.Points[i]
+			if a.X != b.X && a.Y != b.Y {
+				t.Errorf("%s: segment %v-%v is not orthogonal", e.FK.ConstraintName, a, b)
+			}
+			for _, n := range l.Nodes {
+				if crosses(a, b, n) {
+					t.Errorf("%s: segment %v-%v crosses %s", e.FK.ConstraintName, a, b, n.Table.Name)
+				}
+			}
+		}
+	}
+
+	again := layoutTables(tbls, Options{Title: "t"})
+	for i, e := range l.Edges {
+		if len(e.Points) != len(again.Edges[i].Points) {
+			t.Fatalf("layout is not deterministic for %s", e.FK.ConstraintName)
+		}
+	}
+}
+
+func Test_SVG(t *testing.T) {
+	src, err := os.ReadFile("../test_mysql.sql")
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err := SVG(loadTestDDL(t, string(src)), Options{Title: "shop"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	golden := filepath.Join("testdata", "test_mysql.svg")
+	if *update {
+		if err := os.WriteFile(golden, got, 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	want, err := os.ReadFile(golden)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(got, want) {
+		t.Errorf("svg differs from %s, run go test -update to accept the change", golden)
+	}
+}
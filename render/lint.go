@@ -0,0 +1,40 @@
+package render
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+
+	"github.com/maocatooo/planter/lint"
+	"github.com/pkg/errors"
+)
+
+// LintToText plain text report, one line per issue
+func LintToText(issues []lint.Issue) []byte {
+	buf := new(bytes.Buffer)
+	if len(issues) == 0 {
+		buf.WriteString("no issues\n")
+		return buf.Bytes()
+	}
+	for _, is := range issues {
+		target := is.Table
+		if is.Column != "" {
+			target += "." + is.Column
+		}
+		fmt.Fprintf(buf, "%s: %s: %s (%s)\n", is.Severity, target, is.Message, is.Rule)
+	}
+	fmt.Fprintf(buf, "%d issue(s)\n", len(issues))
+	return buf.Bytes()
+}
+
+// LintToJSON issues as a json array
+func LintToJSON(issues []lint.Issue) ([]byte, error) {
+	if issues == nil {
+		issues = []lint.Issue{}
+	}
+	bs, err := json.MarshalIndent(issues, "", "  ")
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to encode lint issues")
+	}
+	return append(bs, '\n'), nil
+}
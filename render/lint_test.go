@@ -0,0 +1,30 @@
+package render
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/maocatooo/planter/lint"
+)
+
+func Test_LintToText(t *testing.T) {
+	out := string(LintToText([]lint.Issue{
+		{Rule: "no-primary-key", Severity: lint.SeverityError, Table: "a", Message: "table has no primary key"},
+		{Rule: "nullable-fk", Severity: lint.SeverityWarning, Table: "b", Column: "a_id", Message: "nullable"},
+	}))
+	for _, want := range []string{
+		"error: a: table has no primary key (no-primary-key)\n",
+		"warning: b.a_id: nullable (nullable-fk)\n",
+		"2 issue(s)\n",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("missing %q in\n%s", want, out)
+		}
+	}
+	if got := string(LintToText(nil)); got != "no issues\n" {
+		t.Errorf("unexpected empty report %q", got)
+	}
+	if got, _ := LintToJSON(nil); string(got) != "[]\n" {
+		t.Errorf("unexpected empty json %q", got)
+	}
+}
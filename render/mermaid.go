@@ -0,0 +1,131 @@
+package render
+
+import (
+	"bytes"
+	"regexp"
+	"strings"
+	"text/template"
+
+	"github.com/maocatooo/planter/schema"
+	"github.com/pkg/errors"
+)
+
+var (
+	mermaidNameExp    = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_-]*$`)
+	mermaidInvalidExp = regexp.MustCompile(`[^A-Za-z0-9_\-()\[\]]+`)
+)
+
+var mermaidFuncs = template.FuncMap{
+	"mermaidName":    mermaidName,
+	"mermaidIdent":   mermaidIdent,
+	"mermaidKeys":    mermaidKeys,
+	"mermaidComment": mermaidComment,
+	"sourceEnd":      sourceEnd,
+	"targetEnd":      targetEnd,
+	"relationLabel":  relationLabel,
+}
+
+// mermaidName entity names outside of the plain identifier syntax have to be quoted
+func mermaidName(s string) string {
+	if mermaidNameExp.MatchString(s) {
+		return s
+	}
+	return `"` + mermaidComment(s) + `"`
+}
+
+// mermaidIdent attribute types and names only allow a restricted charset,
+// e.g. decimal(10,2) becomes decimal(10_2) and enum('a','b') becomes enum
+func mermaidIdent(s string) string {
+	if i := strings.IndexByte(s, '('); i > 0 && strings.ContainsAny(s[i:], `'"`) {
+		s = s[:i]
+	}
+	s = strings.Trim(mermaidInvalidExp.ReplaceAllString(s, "_"), "_")
+	if s == "" {
+		return "_"
+	}
+	if c := s[0]; c >= '0' && c <= '9' || c == '-' || c == '(' || c == '[' {
+		s = "_" + s
+	}
+	return s
+}
+
+func mermaidKeys(c *schema.Column) string {
+	var keys []string
+	if c.IsPrimaryKey {
+		keys = append(keys, "PK")
+	}
+	if c.IsForeignKey {
+		keys = append(keys, "FK")
+	}
+	if c.IsUnique && !c.IsPrimaryKey {
+		keys = append(keys, "UK")
+	}
+	if len(keys) == 0 {
+		return ""
+	}
+	return " " + strings.Join(keys, ", ")
+}
+
+func mermaidComment(s string) string {
+	return strings.NewReplacer(`"`, `'`, "\n", " ", "\r", " ").Replace(s)
+}
+
+// TableToMermaidEntry table entity for mermaid erDiagram
+func TableToMermaidEntry(tbls []*schema.Table) ([]byte, error) {
+	tpl, err := template.New("mermaidEntry").Funcs(mermaidFuncs).Parse(mermaidEntryTmpl)
+	if err != nil {
+		return nil, err
+	}
+	var src []byte
+	for _, tbl := range tbls {
+		buf := new(bytes.Buffer)
+		if err := tpl.Execute(buf, tbl); err != nil {
+			return nil, errors.Wrapf(err, "failed to execute template: %s", tbl.Name)
+		}
+		src = append(src, buf.Bytes()...)
+	}
+	return src, nil
+}
+
+// ForeignKeyToMermaidRelation relationship for mermaid erDiagram
+func ForeignKeyToMermaidRelation(tbls []*schema.Table) ([]byte, error) {
+	tpl, err := template.New("mermaidRelation").Funcs(mermaidFuncs).Parse(mermaidRelationTmpl)
+	if err != nil {
+		return nil, err
+	}
+	var src []byte
+	for _, tbl := range tbls {
+		for _, fk := range tbl.ForeingKeys {
+			buf := new(bytes.Buffer)
+			if err := tpl.Execute(buf, fk); err != nil {
+				return nil, errors.Wrapf(err, "failed to execute template: %s", fk.ConstraintName)
+			}
+			src = append(src, buf.Bytes()...)
+		}
+	}
+	return src, nil
+}
+
+func writeMermaidPrefix(entry, rel []byte, title string) []byte {
+	var src []byte
+	if len(title) != 0 {
+		src = append(src, []byte("---\ntitle: "+mermaidComment(title)+"\n---\n")...)
+	}
+	src = append(src, []byte("erDiagram")...)
+	src = append(src, entry...)
+	src = append(src, rel...)
+	return src
+}
+
+// Mermaid Mermaid erDiagram source of the tables
+func Mermaid(tbls []*schema.Table, opts Options) ([]byte, error) {
+	entry, err := TableToMermaidEntry(tbls)
+	if err != nil {
+		return nil, err
+	}
+	rel, err := ForeignKeyToMermaidRelation(tbls)
+	if err != nil {
+		return nil, err
+	}
+	return writeMermaidPrefix(entry, rel, opts.Title), nil
+}
@@ -0,0 +1,53 @@
+package render
+
+import (
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/maocatooo/planter/driver"
+)
+
+func Test_Mermaid(t *testing.T) {
+	src, err := os.ReadFile("../test_mysql.sql")
+	if err != nil {
+		t.Fatal(err)
+	}
+	tbls, err := driver.ParseDDL(src)
+	if err != nil {
+		t.Fatal(err)
+	}
+	out, err := Mermaid(tbls, Options{Title: "shop"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{
+		"---\ntitle: shop\n---\nerDiagram\n",
+		"    user {\n        int user_id PK \"用户ID\"\n",
+		"        int user_id FK \"用户ID\"\n",
+		"        varchar(100) email UK \"电子邮件\"\n",
+		"        decimal(10_2) price \"商品价格\"\n",
+		"        enum status \"订单状态\"\n",
+		"    cart }o--|| user : \"cart_user_id_fkey\"\n",
+	} {
+		if !strings.Contains(string(out), want) {
+			t.Errorf("missing %q in\n%s", want, out)
+		}
+	}
+}
+
+func Test_mermaidIdent(t *testing.T) {
+	tests := map[string]string{
+		"varchar(255)":             "varchar(255)",
+		"character varying(100)":   "character_varying(100)",
+		"timestamp with time zone": "timestamp_with_time_zone",
+		"int[]":                    "int[]",
+		"2fa_code":                 "_2fa_code",
+		"名字":                       "_",
+	}
+	for in, want := range tests {
+		if got := mermaidIdent(in); got != want {
+			t.Errorf("mermaidIdent(%q) = %q, want %q", in, got, want)
+		}
+	}
+}
@@ -0,0 +1,148 @@
+// Package render draws a schema as PlantUML, Mermaid, Graphviz DOT, SVG or json
+package render
+
+import (
+	"bytes"
+	"fmt"
+	"html/template"
+	"strings"
+
+	"github.com/maocatooo/planter/schema"
+	"github.com/pkg/errors"
+)
+
+// Options drawing settings of the diagram formats
+type Options struct {
+	// Title diagram title
+	Title string
+	// ShowIndexes list the indexes below the columns of each table, not
+	// supported by Mermaid
+	ShowIndexes bool
+}
+
+// entryView table drawn by the entity templates
+type entryView struct {
+	*schema.Table
+	ShowIndexes bool
+}
+
+// indexDef index summary, e.g. (a, b) unique btree where (a > 0)
+func indexDef(ix *schema.Index) string {
+	s := "(" + strings.Join(ix.ColumnNames, ", ") + ")"
+	if ix.Unique {
+		s += " unique"
+	}
+	if ix.Method != "" {
+		s += " " + ix.Method
+	}
+	if ix.Predicate != "" {
+		s += " where " + ix.Predicate
+	}
+	return s
+}
+
+// sourceEnd crow's foot symbol left of the line, PlantUML and Mermaid share the IE notation
+func sourceEnd(c schema.Cardinality) string {
+	switch c {
+	case schema.ExactlyOne:
+		return "||"
+	case schema.ZeroOrMany:
+		return "}o"
+	case schema.OneOrMany:
+		return "}|"
+	}
+	return "|o"
+}
+
+// targetEnd crow's foot symbol right of the line
+func targetEnd(c schema.Cardinality) string {
+	switch c {
+	case schema.ExactlyOne:
+		return "||"
+	case schema.ZeroOrMany:
+		return "o{"
+	case schema.OneOrMany:
+		return "|{"
+	}
+	return "o|"
+}
+
+// relationLabel text drawn on a relation: the collapsed junction table or the
+// share of sampled values found for a sampled inferred foreign key
+func relationLabel(fk *schema.ForeignKey) string {
+	if fk.Junction != nil {
+		return fk.Junction.Name
+	}
+	if fk.Sampled > 0 {
+		return fmt.Sprintf("%.0f%% of %d", fk.Confidence*100, fk.Sampled)
+	}
+	return ""
+}
+
+var relationFuncs = template.FuncMap{
+	"sourceEnd":     sourceEnd,
+	"targetEnd":     targetEnd,
+	"relationLabel": relationLabel,
+}
+
+// TableToUMLEntry table entry
+func TableToUMLEntry(tbls []*schema.Table, opts Options) ([]byte, error) {
+	tpl, err := template.New("entry").Funcs(template.FuncMap{"indexDef": indexDef}).Parse(entryTmpl)
+	if err != nil {
+		return nil, err
+	}
+	var src []byte
+	for _, tbl := range tbls {
+		buf := new(bytes.Buffer)
+		if err := tpl.Execute(buf, entryView{Table: tbl, ShowIndexes: opts.ShowIndexes}); err != nil {
+			return nil, errors.Wrapf(err, "failed to execute template: %s", tbl.Name)
+		}
+		src = append(src, buf.Bytes()...)
+	}
+	return src, nil
+}
+
+// ForeignKeyToUMLRelation relation
+func ForeignKeyToUMLRelation(tbls []*schema.Table) ([]byte, error) {
+	tpl, err := template.New("relation").Funcs(relationFuncs).Parse(relationTmpl)
+	if err != nil {
+		return nil, err
+	}
+	var src []byte
+	for _, tbl := range tbls {
+		for _, fk := range tbl.ForeingKeys {
+			buf := new(bytes.Buffer)
+			if err := tpl.Execute(buf, fk); err != nil {
+				return nil, errors.Wrapf(err, "failed to execute template: %s", fk.ConstraintName)
+			}
+			src = append(src, buf.Bytes()...)
+		}
+	}
+	return src, nil
+}
+
+func writePrefix(entry, rel []byte, title string) []byte {
+	src := []byte("@startuml\n")
+	if len(title) != 0 {
+		src = append(src, []byte("title "+title+"\n")...)
+	}
+	src = append(src, []byte("hide circle\n"+
+		"skinparam linetype ortho\n")...)
+	src = append(src, entry...)
+	src = append(src, rel...)
+	src = append(src, []byte("@enduml\n")...)
+	return src
+}
+
+// PlantUML PlantUML source of the tables
+func PlantUML(tbls []*schema.Table, opts Options) ([]byte, error) {
+	entry, err := TableToUMLEntry(tbls, opts)
+	if err != nil {
+		return nil, err
+	}
+	rel, err := ForeignKeyToUMLRelation(tbls)
+	if err != nil {
+		return nil, err
+	}
+	return writePrefix(entry, rel, opts.Title), nil
+}
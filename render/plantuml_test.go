@@ -0,0 +1,103 @@
+package render
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/maocatooo/planter/schema"
+)
+
+func Test_PlantUML_indexes(t *testing.T) {
+	tbls := loadTestDDL(t, `
+CREATE TABLE post (id int PRIMARY KEY, author_id int NOT NULL, title text, deleted_at timestamp);
+CREATE INDEX post_author_idx ON post (author_id, title) WHERE deleted_at IS NULL;
+`)
+	out, err := PlantUML(tbls, Options{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(string(out), "indexes") {
+		t.Errorf("indexes drawn without ShowIndexes:\n%s", out)
+	}
+
+	out, err = PlantUML(tbls, Options{ShowIndexes: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := `  .. indexes ..
+  ""post_author_idx"": //(author_id, title) btree where deleted_at IS NULL//
+}`
+	if !strings.Contains(string(out), want) {
+		t.Errorf("missing %q in\n%s", want, out)
+	}
+
+	dot, err := Dot(tbls, Options{ShowIndexes: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := `<tr><td align="left"><i>post_author_idx</i></td><td colspan="2" align="left">(author_id, title) btree where deleted_at IS NULL</td></tr>`; !strings.Contains(string(dot), want) {
+		t.Errorf("missing %q in\n%s", want, dot)
+	}
+
+	plain := layoutTables(tbls, Options{}).Nodes[0]
+	indexed := layoutTables(tbls, Options{ShowIndexes: true}).Nodes[0]
+	if indexed.H != plain.H+layoutRowHeight || indexed.W <= plain.W {
+		t.Errorf("node %dx%d with indexes, %dx%d without", indexed.W, indexed.H, plain.W, plain.H)
+	}
+}
+
+func Test_PlantUML_cardinality(t *testing.T) {
+	tbls := loadTestDDL(t, `
+CREATE TABLE account (id int PRIMARY KEY);
+CREATE TABLE profile (account_id int PRIMARY KEY REFERENCES account(id));
+CREATE TABLE login (id int PRIMARY KEY, account_id int NOT NULL REFERENCES account(id));
+CREATE TABLE invite (id int PRIMARY KEY, account_id int REFERENCES account(id));
+CREATE TABLE avatar (id int PRIMARY KEY, account_id int UNIQUE REFERENCES account(id));
+`)
+	out, err := PlantUML(tbls, Options{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{
+		`"**profile**" |o---|| "**account**"`,
+		`"**login**" }o---|| "**account**"`,
+		`"**invite**" }o---o| "**account**"`,
+		`"**avatar**" |o---o| "**account**"`,
+	} {
+		if !strings.Contains(string(out), want) {
+			t.Errorf("missing %q in\n%s", want, out)
+		}
+	}
+}
+
+func Test_relationLabel(t *testing.T) {
+	tbls := loadTestDDL(t, `
+CREATE TABLE account (id int PRIMARY KEY);
+CREATE TABLE login (id int PRIMARY KEY, account_id int NOT NULL);
+`)
+	schema.InferForeignKeys(tbls, schema.InferOptions{})
+	login, _ := schema.FindTableByName(tbls, "login")
+	login.ForeingKeys[0].Sampled, login.ForeingKeys[0].Confidence = 200, 0.975
+
+	out, err := PlantUML(tbls, Options{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := `"**login**" }o...|| "**account**" : 98% of 200`; !strings.Contains(string(out), want) {
+		t.Errorf("missing %q in\n%s", want, out)
+	}
+	out, err = Mermaid(tbls, Options{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := `login }o..|| account : "account_id 98% of 200"`; !strings.Contains(string(out), want) {
+		t.Errorf("missing %q in\n%s", want, out)
+	}
+	out, err = Dot(tbls, Options{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := `label="98% of 200"`; !strings.Contains(string(out), want) {
+		t.Errorf("missing %q in\n%s", want, out)
+	}
+}
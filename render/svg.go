@@ -0,0 +1,109 @@
+package render
+
+import (
+	"bytes"
+	"io"
+	"net/http"
+	"os/exec"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// ErrNoSVGRenderer no PlantUML server or binary configured
+var ErrNoSVGRenderer = errors.New("no svg renderer available: use --plantuml to point to plantuml/plantuml.jar, " +
+	"or --svg-server to use a PlantUML/Kroki server, e.g. https://kroki.io/plantuml/svg")
+
+// SVGRenderer convert PlantUML source to SVG
+type SVGRenderer interface {
+	RenderSVG(plantUMLCode string) ([]byte, error)
+}
+
+// serverRenderer post the source to a Kroki or PlantUML server
+type serverRenderer struct {
+	url    string
+	client *http.Client
+}
+
+// NewServerRenderer render with a server accepting plain text POST requests,
+// e.g. https://kroki.io/plantuml/svg or http://localhost:8080/svg
+func NewServerRenderer(url string) SVGRenderer {
+	return &serverRenderer{
+		url:    url,
+		client: &http.Client{Timeout: time.Minute},
+	}
+}
+
+func (r *serverRenderer) RenderSVG(plantUMLCode string) ([]byte, error) {
+	resp, err := r.client.Post(r.url, "text/plain", bytes.NewBufferString(plantUMLCode))
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to send request")
+	}
+	defer resp.Body.Close()
+
+	bs, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to read response")
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, errors.Errorf("PlantUML server returned an error: %s: %s", resp.Status, bytes.TrimSpace(bs))
+	}
+	return bs, nil
+}
+
+// commandRenderer run a local plantuml binary or jar through stdin/stdout
+type commandRenderer struct {
+	name string
+	args []string
+}
+
+// NewCommandRenderer render with plantuml, path is the plantuml executable or plantuml.jar
+func NewCommandRenderer(path string) SVGRenderer {
+	args := []string{"-tsvg", "-pipe", "-charset", "UTF-8"}
+	if strings.HasSuffix(path, ".jar") {
+		return &commandRenderer{
+			name: "java",
+			args: append([]string{"-Djava.awt.headless=true", "-jar", path}, args...),
+		}
+	}
+	return &commandRenderer{
+		name: path,
+		args: args,
+	}
+}
+
+func (r *commandRenderer) RenderSVG(plantUMLCode string) ([]byte, error) {
+	var stdout, stderr bytes.Buffer
+	cmd := exec.Command(r.name, r.args...)
+	cmd.Stdin = strings.NewReader(plantUMLCode)
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			return nil, errors.Wrapf(err, "%s failed: %s", r.name, msg)
+		}
+		return nil, errors.Wrapf(err, "%s failed", r.name)
+	}
+	return stdout.Bytes(), nil
+}
+
+// NewSVGRenderer choose the renderer, an explicit plantuml path wins over a server URL
+func NewSVGRenderer(server, plantuml string) (SVGRenderer, error) {
+	switch {
+	case plantuml != "":
+		return NewCommandRenderer(plantuml), nil
+	case server != "":
+		return NewServerRenderer(server), nil
+	}
+	return nil, ErrNoSVGRenderer
+}
+
+// ExternalSVG render PlantUML source with r
+func ExternalSVG(r SVGRenderer, plantUMLCode string) ([]byte, error) {
+	bs, err := r.RenderSVG(plantUMLCode)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to render svg")
+	}
+	return bs, nil
+}
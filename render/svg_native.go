@@ -0,0 +1,139 @@
+package render
+
+import (
+	"bytes"
+	"fmt"
+	"html"
+
+	"github.com/maocatooo/planter/schema"
+)
+
+const nativeSVGStyle = `
+  .title { font-size: 16px; font-weight: bold; }
+  .box { fill: #fefece; stroke: #a80036; stroke-width: 1.5; }
+  .header { fill: #f5e6a8; stroke: #a80036; stroke-width: 1.5; }
+  .name { font-weight: bold; }
+  .comment { font-style: italic; fill: #555; }
+  .type { fill: #555; }
+  .key { fill: #a80036; }
+  .sep { stroke: #a80036; stroke-width: 1; }
+  .rel { fill: none; stroke: #a80036; stroke-width: 1.2; }
+  .rel circle { fill: #fff; }
+  .rel .inferred { stroke-dasharray: 5 3; }
+  .rel text { fill: #555; stroke: none; font-style: italic; }
+`
+
+// SVG lay out and draw the diagram without PlantUML
+func SVG(tbls []*schema.Table, opts Options) ([]byte, error) {
+	l := layoutTables(tbls, opts)
+	title := opts.Title
+	buf := new(bytes.Buffer)
+	fmt.Fprintf(buf, "<?xml version=\"1.0\" encoding=\"UTF-8\"?>\n")
+	fmt.Fprintf(buf, "<svg xmlns=\"http://www.w3.org/2000/svg\" width=\"%d\" height=\"%d\" viewBox=\"0 0 %d %d\" font-family=\"monospace\" font-size=\"12\">\n",
+		l.Width, l.Height, l.Width, l.Height)
+	fmt.Fprintf(buf, "<style>%s</style>\n", nativeSVGStyle)
+	fmt.Fprintf(buf, "<rect width=\"100%%\" height=\"100%%\" fill=\"white\"/>\n")
+	if title != "" {
+		fmt.Fprintf(buf, "<text class=\"title\" x=\"%d\" y=\"%d\" text-anchor=\"middle\">%s</text>\n",
+			l.Width/2, layoutMargin+layoutTitleH/2, html.EscapeString(title))
+	}
+	for _, e := range l.Edges {
+		writeSVGEdge(buf, e)
+	}
+	for _, n := range l.Nodes {
+		writeSVGNode(buf, n)
+	}
+	fmt.Fprintf(buf, "</svg>\n")
+	return buf.Bytes(), nil
+}
+
+func writeSVGNode(buf *bytes.Buffer, n *layoutNode) {
+	fmt.Fprintf(buf, "<g id=%q>\n", "table-"+n.Table.Name)
+	fmt.Fprintf(buf, "  <rect class=\"box\" x=\"%d\" y=\"%d\" width=\"%d\" height=\"%d\"/>\n", n.X, n.Y, n.W, n.H)
+	fmt.Fprintf(buf, "  <rect class=\"header\" x=\"%d\" y=\"%d\" width=\"%d\" height=\"%d\"/>\n", n.X, n.Y, n.W, layoutHeaderH)
+	fmt.Fprintf(buf, "  <text class=\"name\" x=\"%d\" y=\"%d\" text-anchor=\"middle\">%s</text>\n",
+		n.X+n.W/2, n.Y+layoutHeaderH-8, html.EscapeString(n.Table.Name))
+	if n.bodyTop() > layoutHeaderH {
+		fmt.Fprintf(buf, "  <text class=\"comment\" x=\"%d\" y=\"%d\">%s</text>\n",
+			n.X+layoutPadding, n.Y+layoutHeaderH+layoutRowHeight-6, html.EscapeString(n.Table.Comment.String))
+	}
+	nameX := n.X + layoutPadding
+	typeX := nameX + n.NameW + layoutColumnGap
+	markX := typeX + n.TypeW + layoutColumnGap
+	for i, c := range n.Rows {
+		y := n.Y + n.bodyTop() + i*layoutRowHeight
+		if i == n.PKRows && i > 0 {
+			fmt.Fprintf(buf, "  <line class=\"sep\" x1=\"%d\" y1=\"%d\" x2=\"%d\" y2=\"%d\"/>\n", n.X, y, n.X+n.W, y)
+		}
+		fmt.Fprintf(buf, "  <text x=\"%d\" y=\"%d\">%s</text>\n", nameX, y+layoutRowHeight-6, html.EscapeString(columnLabel(c)))
+		fmt.Fprintf(buf, "  <text class=\"type\" x=\"%d\" y=\"%d\">%s</text>\n", typeX, y+layoutRowHeight-6, html.EscapeString(c.DataType))
+		if m := columnMarkers(c); m != "" {
+			fmt.Fprintf(buf, "  <text class=\"key\" x=\"%d\" y=\"%d\">%s</text>\n", markX, y+layoutRowHeight-6, m)
+		}
+	}
+	for i, ix := range n.Indexes {
+		y := n.Y + n.bodyTop() + (len(n.Rows)+i)*layoutRowHeight
+		if i == 0 {
+			fmt.Fprintf(buf, "  <line class=\"sep\" x1=\"%d\" y1=\"%d\" x2=\"%d\" y2=\"%d\"/>\n", n.X, y, n.X+n.W, y)
+		}
+		fmt.Fprintf(buf, "  <text class=\"comment\" x=\"%d\" y=\"%d\">%s</text>\n", nameX, y+layoutRowHeight-6, html.EscapeString(ix.Name))
+		fmt.Fprintf(buf, "  <text class=\"type\" x=\"%d\" y=\"%d\">%s</text>\n", typeX, y+layoutRowHeight-6, html.EscapeString(indexDef(ix)))
+	}
+	fmt.Fprintf(buf, "</g>\n")
+}
+
+func writeSVGEdge(buf *bytes.Buffer, e *layoutEdge) {
+	fmt.Fprintf(buf, "<g class=\"rel\">\n")
+	fmt.Fprintf(buf, "  <title>%s</title>\n", html.EscapeString(e.FK.ConstraintName))
+	if e.FK.Inferred {
+		fmt.Fprintf(buf, "  <path class=\"inferred\" d=\"")
+	} else {
+		fmt.Fprintf(buf, "  <path d=\"")
+	}
+	for i, p := range e.Points {
+		cmd := "L"
+		if i == 0 {
+			cmd = "M"
+		}
+		fmt.Fprintf(buf, "%s%d %d ", cmd, p.X, p.Y)
+	}
+	buf.Truncate(buf.Len() - 1)
+	fmt.Fprintf(buf, "\"/>\n")
+	last := len(e.Points) - 1
+	writeSVGEnd(buf, e.Points[0], e.Points[1], e.FK.SourceCardinality())
+	writeSVGEnd(buf, e.Points[last], e.Points[last-1], e.FK.TargetCardinality())
+	if label := relationLabel(e.FK); label != "" {
+		a, b := e.Points[(last-1)/2], e.Points[(last+1)/2]
+		fmt.Fprintf(buf, "  <text x=\"%d\" y=\"%d\" text-anchor=\"middle\">%s</text>\n",
+			(a.X+b.X)/2, (a.Y+b.Y)/2-4, html.EscapeString(label))
+	}
+	fmt.Fprintf(buf, "</g>\n")
+}
+
+func direction(from, to point) int {
+	if to.X < from.X {
+		return -1
+	}
+	return 1
+}
+
+// writeSVGEnd crow's foot marker at the table side, a bar or a crow's foot
+// for the maximum and a bar or a circle for the minimum further out
+func writeSVGEnd(buf *bytes.Buffer, end, next point, c schema.Cardinality) {
+	d := direction(end, next)
+	if c == schema.ZeroOrMany || c == schema.OneOrMany {
+		x := end.X + d*12
+		fmt.Fprintf(buf, "  <path d=\"M%d %d L%d %d L%d %d\"/>\n", end.X, end.Y-7, x, end.Y, end.X, end.Y+7)
+	} else {
+		writeSVGBar(buf, end.X+d*6, end.Y)
+	}
+	if c == schema.ExactlyOne || c == schema.OneOrMany {
+		writeSVGBar(buf, end.X+d*14, end.Y)
+	} else {
+		fmt.Fprintf(buf, "  <circle cx=\"%d\" cy=\"%d\" r=\"3\"/>\n", end.X+d*16, end.Y)
+	}
+}
+
+func writeSVGBar(buf *bytes.Buffer, x, y int) {
+	fmt.Fprintf(buf, "  <path d=\"M%d %d L%d %d\"/>\n", x, y-6, x, y+6)
+}
@@ -0,0 +1,86 @@
+package render
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+)
+
+func Test_ExternalSVG(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		bs, _ := io.ReadAll(r.Body)
+		if r.Method != http.MethodPost || !strings.HasPrefix(string(bs), "@startuml") {
+			http.Error(w, "bad diagram", http.StatusBadRequest)
+			return
+		}
+		w.Write([]byte("<svg/>"))
+	}))
+	defer srv.Close()
+
+	r := NewServerRenderer(srv.URL)
+	got, err := ExternalSVG(r, "@startuml\n@enduml\n")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(got) != "<svg/>" {
+		t.Errorf("got %q", got)
+	}
+
+	_, err = ExternalSVG(r, "oops")
+	if err == nil || !strings.Contains(err.Error(), "bad diagram") {
+		t.Errorf("expected server error, got %v", err)
+	}
+}
+
+func Test_commandRenderer(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("shell script stand-in")
+	}
+	bin := filepath.Join(t.TempDir(), "plantuml")
+	script := "#!/bin/sh\n" +
+		"[ \"$1\" = -tsvg ] || { echo \"unexpected $1\" >&2; exit 1; }\n" +
+		"printf '<svg>'; cat; printf '</svg>'\n"
+	if err := os.WriteFile(bin, []byte(script), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	got, err := ExternalSVG(NewCommandRenderer(bin), "x")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(got) != "<svg>x</svg>" {
+		t.Errorf("got %q", got)
+	}
+
+	fail := filepath.Join(t.TempDir(), "plantuml")
+	if err := os.WriteFile(fail, []byte("#!/bin/sh\necho broken >&2\nexit 2\n"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := ExternalSVG(NewCommandRenderer(fail), "x"); err == nil || !strings.Contains(err.Error(), "broken") {
+		t.Errorf("expected stderr in error, got %v", err)
+	}
+}
+
+func Test_NewSVGRenderer(t *testing.T) {
+	if _, err := NewSVGRenderer("", ""); err != ErrNoSVGRenderer {
+		t.Errorf("expected ErrNoSVGRenderer, got %v", err)
+	}
+	r, err := NewSVGRenderer("http://localhost:8080/svg", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := r.(*serverRenderer); !ok {
+		t.Errorf("expected server renderer, got %T", r)
+	}
+	r, err = NewSVGRenderer("http://localhost:8080/svg", "/opt/plantuml.jar")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if c, ok := r.(*commandRenderer); !ok || c.name != "java" {
+		t.Errorf("expected java command renderer, got %#v", r)
+	}
+}
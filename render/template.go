@@ -0,0 +1,89 @@
+package render
+
+const entryTmpl = `
+entity "**{{ .Name }}**" {
+{{- if .Comment.Valid  }}
+  {{ .Comment.String }}
+  ..
+{{- end }}
+{{- range .Columns }}
+  {{- if .IsPrimaryKey }}
+  + ""{{ .Name }}"": //{{ .DataType }} [PK]{{if .IsForeignKey }}[FK]{{end}}{{- if .Comment.Valid }} : {{ .Comment.String }}{{- end }}//
+  {{- end }}
+{{- end }}
+  --
+{{- range .Columns }}
+  {{- if not .IsPrimaryKey }}
+  {{if .NotNull}}*{{end}}""{{ .Name }}"": //{{ .DataType }} {{if .IsForeignKey}}[FK]{{end}}{{if .IsUnique}}[UK]{{end}} {{- if .Comment.Valid }} : {{ .Comment.String }}{{- end }}//
+  {{- end }}
+{{- end }}
+{{- if and .ShowIndexes .Indexes }}
+  .. indexes ..
+{{- range .Indexes }}
+  ""{{ .Name }}"": //{{ indexDef . }}//
+{{- end }}
+{{- end }}
+}
+`
+
+const relationTmpl = `
+"**{{ .SourceTableName }}**" {{ sourceEnd .SourceCardinality }}{{if .Inferred}}...{{else}}---{{end}}{{ targetEnd .TargetCardinality }} "**{{ .TargetTableName }}**"{{with relationLabel .}} : {{ . }}{{end}}
+`
+
+const mermaidEntryTmpl = `
+    {{ mermaidName .Name }} {
+{{- range .Columns }}
+        {{ mermaidIdent .DataType }} {{ mermaidIdent .Name }}{{ mermaidKeys . }}{{- if .Comment.Valid }} "{{ mermaidComment .Comment.String }}"{{- end }}
+{{- end }}
+    }
+`
+
+const mermaidRelationTmpl = `
+    {{ mermaidName .SourceTableName }} {{ sourceEnd .SourceCardinality }}{{if .Inferred}}..{{else}}--{{end}}{{ targetEnd .TargetCardinality }} {{ mermaidName .TargetTableName }} : "{{ mermaidComment .ConstraintName }}{{with relationLabel .}} {{ mermaidComment . }}{{end}}"
+`
+
+const dotEntryTmpl = `
+  {{ dotID .Name }} [label=<
+    <table border="0" cellborder="1" cellspacing="0" cellpadding="4">
+      <tr><td colspan="3" bgcolor="lightgrey"><b>{{ dotEscape .Name }}</b></td></tr>
+{{- if .Comment.Valid }}
+      <tr><td colspan="3"><i>{{ dotEscape .Comment.String }}</i></td></tr>
+{{- end }}
+{{- range .Columns }}
+      <tr><td port="{{ dotPort . }}" align="left">{{if .IsPrimaryKey}}<u>{{ dotEscape .Name }}</u>{{else}}{{ dotEscape .Name }}{{end}}</td><td align="left">{{ dotEscape .DataType }}</td><td align="left">{{ dotMarkers . }}</td></tr>
+{{- end }}
+{{- if and .ShowIndexes .Indexes }}
+{{- range .Indexes }}
+      <tr><td align="left"><i>{{ dotEscape .Name }}</i></td><td colspan="2" align="left">{{ dotEscape (indexDef .) }}</td></tr>
+{{- end }}
+{{- end }}
+    </table>>];
+`
+
+const dotRelationTmpl = `
+  {{ dotEndpoint .SourceTableName .SourceTable .SourceColumn }} -> {{ dotEndpoint .TargetTableName .TargetTable .TargetColumn }} [arrowtail={{ dotArrow .SourceCardinality }}, arrowhead={{ dotArrow .TargetCardinality }}, tooltip={{ dotID .ConstraintName }}{{with relationLabel .}}, label={{ dotID . }}{{end}}{{if .Inferred}}, style=dashed{{end}}];
+`
+
+const diffEntryTmpl = `
+entity "**{{ .Name }}**" {{ diffBackground .Status }}{
+{{- if .Comment.Valid  }}
+  {{ .Comment.String }}
+  ..
+{{- end }}
+{{- range .Columns }}
+  {{- if .IsPrimaryKey }}
+  + {{ diffOpen .Status }}""{{ .Name }}"": //{{ .DataType }} [PK]{{if .IsForeignKey }}[FK]{{end}}{{- if .Comment.Valid }} : {{ .Comment.String }}{{- end }}//{{ diffClose .Status }}
+  {{- end }}
+{{- end }}
+  --
+{{- range .Columns }}
+  {{- if not .IsPrimaryKey }}
+  {{if .NotNull}}*{{end}}{{ diffOpen .Status }}""{{ .Name }}"": //{{ .DataType }} {{if .IsForeignKey}}[FK]{{end}}{{if .IsUnique}}[UK]{{end}} {{- if .Comment.Valid }} : {{ .Comment.String }}{{- end }}//{{ diffClose .Status }}
+  {{- end }}
+{{- end }}
+}
+`
+
+const diffRelationTmpl = `
+"**{{ .SourceTableName }}**" {{ sourceEnd .SourceCardinality }}-{{ diffLink .Status }}-{{ targetEnd .TargetCardinality }} "**{{ .TargetTableName }}**"
+`
@@ -0,0 +1,56 @@
+package schema
+
+import (
+	"path"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// ColumnFilter report whether a column is hidden, patterns are column globs
+// (created_at) or table.column globs (audit_*.payload), keysOnly hides every
+// column not in the primary key or a foreign key
+func ColumnFilter(patterns []string, keysOnly bool) (func(tbl *Table, c *Column) bool, error) {
+	type columnPattern struct{ table, column string }
+	var cps []columnPattern
+	for _, p := range patterns {
+		cp := columnPattern{table: "*", column: p}
+		if i := strings.Index(p, "."); i >= 0 {
+			cp = columnPattern{table: p[:i], column: p[i+1:]}
+		}
+		for _, glob := range []string{cp.table, cp.column} {
+			if _, err := path.Match(glob, ""); err != nil || glob == "" {
+				return nil, errors.Errorf("invalid column pattern %s", p)
+			}
+		}
+		cps = append(cps, cp)
+	}
+	return func(tbl *Table, c *Column) bool {
+		if keysOnly && !c.IsPrimaryKey && !c.IsForeignKey {
+			return true
+		}
+		for _, cp := range cps {
+			tok, _ := path.Match(cp.table, tbl.Name)
+			cok, _ := path.Match(cp.column, c.Name)
+			if tok && cok {
+				return true
+			}
+		}
+		return false
+	}, nil
+}
+
+// HideColumns copies of tbls without the columns hidden by hide, tbls is not modified
+func HideColumns(tbls []*Table, hide func(tbl *Table, c *Column) bool) []*Table {
+	target := retain(tbls, func(string) bool { return true })
+	for _, tbl := range target {
+		var cols []*Column
+		for _, c := range tbl.Columns {
+			if !hide(tbl, c) {
+				cols = append(cols, c)
+			}
+		}
+		tbl.Columns = cols
+	}
+	return target
+}
@@ -0,0 +1,261 @@
+package schema
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// SchemaDiff structural changes between two schemas
+type SchemaDiff struct {
+	AddedTables   []*Table
+	RemovedTables []*Table
+	ChangedTables []*TableDiff
+}
+
+// TableDiff changes of a table existing in both schemas
+type TableDiff struct {
+	Name               string
+	From               *Table
+	To                 *Table
+	CommentChanged     bool
+	AddedColumns       []*Column
+	RemovedColumns     []*Column
+	ChangedColumns     []*ColumnDiff
+	AddedForeignKeys   []*ForeignKey
+	RemovedForeignKeys []*ForeignKey
+	AddedIndexes       []*Index
+	RemovedIndexes     []*Index
+	ChangedIndexes     []*IndexDiff
+}
+
+// ColumnDiff changes of a column existing in both schemas
+type ColumnDiff struct {
+	Name    string
+	From    *Column
+	To      *Column
+	Changes []string
+}
+
+// IndexDiff changes of an index existing in both schemas, indexes are matched by name
+type IndexDiff struct {
+	Name    string
+	From    *Index
+	To      *Index
+	Changes []string
+}
+
+// Empty no difference found
+func (d *SchemaDiff) Empty() bool {
+	return len(d.AddedTables) == 0 && len(d.RemovedTables) == 0 && len(d.ChangedTables) == 0
+}
+
+func (d *TableDiff) empty() bool {
+	return !d.CommentChanged && len(d.AddedColumns) == 0 && len(d.RemovedColumns) == 0 &&
+		len(d.ChangedColumns) == 0 && len(d.AddedForeignKeys) == 0 && len(d.RemovedForeignKeys) == 0 &&
+		len(d.AddedIndexes) == 0 && len(d.RemovedIndexes) == 0 && len(d.ChangedIndexes) == 0
+}
+
+// fkKey foreign keys are compared by columns, constraint names differ between environments
+func fkKey(fk *ForeignKey) string {
+	return fmt.Sprintf("%s.%s->%s.%s", fk.SourceTableName, strings.Join(fk.SourceColNames(), ","),
+		fk.TargetTableName, strings.Join(fk.TargetColNames(), ","))
+}
+
+// bySource copy tbls listing every foreign key on its source table, MySQL
+// lists them on the referenced table, the foreign keys and columns are shared
+func bySource(tbls []*Table) []*Table {
+	fks := map[string][]*ForeignKey{}
+	for _, tbl := range tbls {
+		for _, fk := range tbl.ForeingKeys {
+			fks[fk.SourceTableName] = append(fks[fk.SourceTableName], fk)
+		}
+	}
+	cps := make([]*Table, 0, len(tbls))
+	for _, tbl := range tbls {
+		cp := *tbl
+		cp.ForeingKeys = fks[tbl.Name]
+		cps = append(cps, &cp)
+	}
+	return cps
+}
+
+func commentString(c *Column) string {
+	if !c.Comment.Valid {
+		return ""
+	}
+	return c.Comment.String
+}
+
+func nullability(notNull bool) string {
+	if notNull {
+		return "NOT NULL"
+	}
+	return "NULL"
+}
+
+// defaultString quoted default of c, none when it has no default
+func defaultString(c *Column) string {
+	if !c.Default.Valid {
+		return "none"
+	}
+	return fmt.Sprintf("%q", c.Default.String)
+}
+
+func diffColumn(from, to *Column) *ColumnDiff {
+	d := &ColumnDiff{Name: to.Name, From: from, To: to}
+	if from.DataType != to.DataType {
+		d.Changes = append(d.Changes, fmt.Sprintf("type %s -> %s", from.DataType, to.DataType))
+	}
+	if from.NotNull != to.NotNull {
+		d.Changes = append(d.Changes, fmt.Sprintf("%s -> %s", nullability(from.NotNull), nullability(to.NotNull)))
+	}
+	if defaultString(from) != defaultString(to) {
+		d.Changes = append(d.Changes, fmt.Sprintf("default %s -> %s", defaultString(from), defaultString(to)))
+	}
+	if from.Extra != to.Extra {
+		d.Changes = append(d.Changes, fmt.Sprintf("extra %q -> %q", from.Extra, to.Extra))
+	}
+	if from.IsPrimaryKey != to.IsPrimaryKey {
+		if to.IsPrimaryKey {
+			d.Changes = append(d.Changes, "added to primary key")
+		} else {
+			d.Changes = append(d.Changes, "removed from primary key")
+		}
+	}
+	if from.IsUnique != to.IsUnique {
+		if to.IsUnique {
+			d.Changes = append(d.Changes, "made unique")
+		} else {
+			d.Changes = append(d.Changes, "no longer unique")
+		}
+	}
+	if commentString(from) != commentString(to) {
+		d.Changes = append(d.Changes, fmt.Sprintf("comment %q -> %q", commentString(from), commentString(to)))
+	}
+	if len(d.Changes) == 0 {
+		return nil
+	}
+	return d
+}
+
+func diffIndex(from, to *Index) *IndexDiff {
+	d := &IndexDiff{Name: to.Name, From: from, To: to}
+	if fc, tc := strings.Join(from.ColumnNames, ", "), strings.Join(to.ColumnNames, ", "); fc != tc {
+		d.Changes = append(d.Changes, fmt.Sprintf("columns (%s) -> (%s)", fc, tc))
+	}
+	if from.Unique != to.Unique {
+		if to.Unique {
+			d.Changes = append(d.Changes, "made unique")
+		} else {
+			d.Changes = append(d.Changes, "no longer unique")
+		}
+	}
+	if from.Method != to.Method {
+		d.Changes = append(d.Changes, fmt.Sprintf("method %s -> %s", from.Method, to.Method))
+	}
+	if from.Predicate != to.Predicate {
+		d.Changes = append(d.Changes, fmt.Sprintf("predicate %q -> %q", from.Predicate, to.Predicate))
+	}
+	if len(d.Changes) == 0 {
+		return nil
+	}
+	return d
+}
+
+func diffTable(from, to *Table) *TableDiff {
+	d := &TableDiff{Name: to.Name, From: from, To: to}
+	d.CommentChanged = from.Comment.Valid != to.Comment.Valid || from.Comment.String != to.Comment.String
+
+	fromCols := map[string]*Column{}
+	for _, c := range from.Columns {
+		fromCols[c.Name] = c
+	}
+	toCols := map[string]*Column{}
+	for _, c := range to.Columns {
+		toCols[c.Name] = c
+		fc, ok := fromCols[c.Name]
+		if !ok {
+			d.AddedColumns = append(d.AddedColumns, c)
+			continue
+		}
+		if cd := diffColumn(fc, c); cd != nil {
+			d.ChangedColumns = append(d.ChangedColumns, cd)
+		}
+	}
+	for _, c := range from.Columns {
+		if _, ok := toCols[c.Name]; !ok {
+			d.RemovedColumns = append(d.RemovedColumns, c)
+		}
+	}
+
+	fromFks := map[string]bool{}
+	for _, fk := range from.ForeingKeys {
+		fromFks[fkKey(fk)] = true
+	}
+	toFks := map[string]bool{}
+	for _, fk := range to.ForeingKeys {
+		toFks[fkKey(fk)] = true
+		if !fromFks[fkKey(fk)] {
+			d.AddedForeignKeys = append(d.AddedForeignKeys, fk)
+		}
+	}
+	for _, fk := range from.ForeingKeys {
+		if !toFks[fkKey(fk)] {
+			d.RemovedForeignKeys = append(d.RemovedForeignKeys, fk)
+		}
+	}
+
+	fromIdxs := map[string]*Index{}
+	for _, ix := range from.Indexes {
+		fromIdxs[ix.Name] = ix
+	}
+	toIdxs := map[string]*Index{}
+	for _, ix := range to.Indexes {
+		toIdxs[ix.Name] = ix
+		fi, ok := fromIdxs[ix.Name]
+		if !ok {
+			d.AddedIndexes = append(d.AddedIndexes, ix)
+			continue
+		}
+		if id := diffIndex(fi, ix); id != nil {
+			d.ChangedIndexes = append(d.ChangedIndexes, id)
+		}
+	}
+	for _, ix := range from.Indexes {
+		if _, ok := toIdxs[ix.Name]; !ok {
+			d.RemovedIndexes = append(d.RemovedIndexes, ix)
+		}
+	}
+	if d.empty() {
+		return nil
+	}
+	return d
+}
+
+// DiffTables compare two schemas, from is the current state and to the desired
+// one. Foreign keys are compared on their source table whichever table the
+// driver listed them on, the tables of the diff are copies listing them there
+func DiffTables(from, to []*Table) *SchemaDiff {
+	from, to = bySource(from), bySource(to)
+	d := &SchemaDiff{}
+	for _, t := range to {
+		ft, found := FindTableByName(from, t.Name)
+		if !found {
+			d.AddedTables = append(d.AddedTables, t)
+			continue
+		}
+		if td := diffTable(ft, t); td != nil {
+			d.ChangedTables = append(d.ChangedTables, td)
+		}
+	}
+	for _, t := range from {
+		if _, found := FindTableByName(to, t.Name); !found {
+			d.RemovedTables = append(d.RemovedTables, t)
+		}
+	}
+	sort.SliceStable(d.AddedTables, func(i, j int) bool { return d.AddedTables[i].Name < d.AddedTables[j].Name })
+	sort.SliceStable(d.RemovedTables, func(i, j int) bool { return d.RemovedTables[i].Name < d.RemovedTables[j].Name })
+	sort.SliceStable(d.ChangedTables, func(i, j int) bool { return d.ChangedTables[i].Name < d.ChangedTables[j].Name })
+	return d
+}
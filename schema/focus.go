@@ -0,0 +1,71 @@
+package schema
+
+import (
+	"github.com/pkg/errors"
+)
+
+// Direction foreign keys followed by FocusTables
+type Direction string
+
+// Directions of FocusTables
+const (
+	// DirectionBoth referenced and referencing tables
+	DirectionBoth Direction = "both"
+	// DirectionOut tables referenced by the kept tables
+	DirectionOut Direction = "out"
+	// DirectionIn tables referencing the kept tables
+	DirectionIn Direction = "in"
+)
+
+// FocusTables keep the roots and the tables within depth foreign key hops of
+// them, foreign keys to dropped tables are removed, tbls is not modified
+func FocusTables(tbls []*Table, roots []string, depth int, dir Direction) ([]*Table, error) {
+	if dir == "" {
+		dir = DirectionBoth
+	}
+	if dir != DirectionBoth && dir != DirectionOut && dir != DirectionIn {
+		return nil, errors.Errorf("unknown direction %s, use both/out/in", dir)
+	}
+	if depth < 0 {
+		return nil, errors.Errorf("depth must not be negative, got %d", depth)
+	}
+
+	// MySQL lists foreign keys on the referenced table, use the names of both ends
+	next := map[string][]string{}
+	for _, tbl := range tbls {
+		for _, fk := range tbl.ForeingKeys {
+			if dir != DirectionIn {
+				next[fk.SourceTableName] = append(next[fk.SourceTableName], fk.TargetTableName)
+			}
+			if dir != DirectionOut {
+				next[fk.TargetTableName] = append(next[fk.TargetTableName], fk.SourceTableName)
+			}
+		}
+	}
+
+	kept := map[string]bool{}
+	var frontier []string
+	for _, name := range roots {
+		if _, found := FindTableByName(tbls, name); !found {
+			return nil, errors.Errorf("focus table %s not found", name)
+		}
+		if !kept[name] {
+			kept[name] = true
+			frontier = append(frontier, name)
+		}
+	}
+	for hop := 0; hop < depth && len(frontier) != 0; hop++ {
+		var reached []string
+		for _, name := range frontier {
+			for _, n := range next[name] {
+				if !kept[n] {
+					kept[n] = true
+					reached = append(reached, n)
+				}
+			}
+		}
+		frontier = reached
+	}
+
+	return retain(tbls, func(name string) bool { return kept[name] }), nil
+}
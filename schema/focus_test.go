@@ -0,0 +1,69 @@
+package schema
+
+import (
+	"reflect"
+	"testing"
+)
+
+// chainTables a -> b -> c -> d, e references b, f is unrelated
+func chainTables() []*Table {
+	tbls := []*Table{{Name: "a"}, {Name: "b"}, {Name: "c"}, {Name: "d"}, {Name: "e"}, {Name: "f"}}
+	link := func(src, dst int) {
+		tbls[src].ForeingKeys = append(tbls[src].ForeingKeys, &ForeignKey{
+			ConstraintName:  tbls[src].Name + "_" + tbls[dst].Name,
+			SourceTableName: tbls[src].Name,
+			SourceTable:     tbls[src],
+			TargetTableName: tbls[dst].Name,
+			TargetTable:     tbls[dst],
+		})
+	}
+	link(0, 1)
+	link(1, 2)
+	link(2, 3)
+	link(4, 1)
+	return tbls
+}
+
+func tableNames(tbls []*Table) []string {
+	var names []string
+	for _, t := range tbls {
+		names = append(names, t.Name)
+	}
+	return names
+}
+
+func Test_FocusTables(t *testing.T) {
+	for _, tc := range []struct {
+		depth int
+		dir   Direction
+		want  []string
+	}{
+		{0, DirectionBoth, []string{"b"}},
+		{1, DirectionBoth, []string{"a", "b", "c", "e"}},
+		{2, DirectionBoth, []string{"a", "b", "c", "d", "e"}},
+		{2, DirectionOut, []string{"b", "c", "d"}},
+		{2, DirectionIn, []string{"a", "b", "e"}},
+	} {
+		got, err := FocusTables(chainTables(), []string{"b"}, tc.depth, tc.dir)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !reflect.DeepEqual(tableNames(got), tc.want) {
+			t.Errorf("depth %d %s: got %v, want %v", tc.depth, tc.dir, tableNames(got), tc.want)
+		}
+		for _, tbl := range got {
+			for _, fk := range tbl.ForeingKeys {
+				if _, found := FindTableByName(got, fk.TargetTableName); !found {
+					t.Errorf("depth %d %s: %s references dropped table %s", tc.depth, tc.dir, tbl.Name, fk.TargetTableName)
+				}
+			}
+		}
+	}
+
+	if _, err := FocusTables(chainTables(), []string{"x"}, 1, DirectionBoth); err == nil {
+		t.Error("expected error for unknown focus table")
+	}
+	if _, err := FocusTables(chainTables(), []string{"b"}, 1, "sideways"); err == nil {
+		t.Error("expected error for unknown direction")
+	}
+}
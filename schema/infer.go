@@ -0,0 +1,211 @@
+package schema
+
+import (
+	"strings"
+)
+
+// InferMode tables foreign keys are inferred for
+type InferMode string
+
+// Modes of InferForeignKeys
+const (
+	// InferAuto every table, only when no table has a foreign key
+	InferAuto InferMode = "auto"
+	// InferMissing the tables without foreign keys, alongside the real ones
+	InferMissing InferMode = "missing"
+	// InferOff no inference
+	InferOff InferMode = "off"
+)
+
+// DefaultInferSuffixes column suffixes marking a reference, e.g. user_id, userId, user_fk
+var DefaultInferSuffixes = []string{"_id", "Id", "_fk"}
+
+// InferOptions settings of InferForeignKeys
+type InferOptions struct {
+	// Mode Default InferAuto
+	Mode InferMode
+	// Suffixes column suffixes marking a reference, Default DefaultInferSuffixes
+	Suffixes []string
+	// Prefixes table name prefixes ignored when matching, e.g. tbl_, t_
+	Prefixes []string
+}
+
+// ForeignKeyAnalysis use foreign key analysis if all table not set fk
+func ForeignKeyAnalysis(tables []*Table) {
+	InferForeignKeys(tables, InferOptions{})
+}
+
+// InferForeignKeys add Inferred foreign keys from column names to single
+// column primary keys: orders.user_id, orders.userId or orders.user_fk
+// reference users.id, t_orders.user_id references tbl_user.id with the
+// prefixes t_ and tbl_, and orders.country_code references country.code.
+// Both columns must have compatible types, the foreign keys are listed on the
+// referencing table
+func InferForeignKeys(tables []*Table, opts InferOptions) {
+	mode := opts.Mode
+	if mode == "" {
+		mode = InferAuto
+	}
+	suffixes := opts.Suffixes
+	if len(suffixes) == 0 {
+		suffixes = DefaultInferSuffixes
+	}
+	if mode == InferOff {
+		return
+	}
+
+	// MySQL lists foreign keys on the referenced table, collect them by source
+	hasFK := map[string]bool{}
+	for _, tbl := range tables {
+		for _, fk := range tbl.ForeingKeys {
+			hasFK[fk.SourceTableName] = true
+		}
+	}
+	if mode == InferAuto && len(hasFK) != 0 {
+		return
+	}
+
+	for _, src := range tables {
+		if hasFK[src.Name] {
+			continue
+		}
+		for _, col := range src.Columns {
+			if col.IsPrimaryKey && !src.IsCompositePK() {
+				continue
+			}
+			target, pk, found := inferTarget(tables, src, col, suffixes, opts.Prefixes)
+			if !found {
+				continue
+			}
+			col.IsForeignKey = true
+			src.ForeingKeys = append(src.ForeingKeys, &ForeignKey{
+				ConstraintName:        col.Name,
+				SourceTableName:       src.Name,
+				SourceColName:         col.Name,
+				IsSourceColPrimaryKey: col.IsPrimaryKey,
+				SourceTable:           src,
+				SourceColumn:          col,
+				TargetTableName:       target.Name,
+				TargetColName:         pk.Name,
+				IsTargetColPrimaryKey: true,
+				TargetTable:           target,
+				TargetColumn:          pk,
+				Inferred:              true,
+			})
+		}
+	}
+}
+
+// DropUnconfirmed remove the sampled Inferred foreign keys whose Confidence
+// is below min, their source columns are no longer marked as foreign keys.
+// Foreign keys that were not sampled are kept
+func DropUnconfirmed(tables []*Table, min float64) {
+	for _, tbl := range tables {
+		var fks []*ForeignKey
+		for _, fk := range tbl.ForeingKeys {
+			if fk.Inferred && fk.Sampled > 0 && fk.Confidence < min {
+				if fk.SourceColumn != nil {
+					fk.SourceColumn.IsForeignKey = false
+				}
+				continue
+			}
+			fks = append(fks, fk)
+		}
+		tbl.ForeingKeys = fks
+	}
+}
+
+// inferTarget table referenced by col, exact table names win over plural forms
+func inferTarget(tables []*Table, src *Table, col *Column, suffixes, prefixes []string) (*Table, *Column, bool) {
+	var (
+		plural   *Table
+		pluralPK *Column
+	)
+	for _, tbl := range tables {
+		pk := singlePK(tbl)
+		if tbl == src || pk == nil || !compatibleTypes(col.DataType, pk.DataType) {
+			continue
+		}
+		name := strings.ToLower(stripPrefix(tbl.Name, prefixes))
+		for _, stem := range referenceStems(col.Name, pk.Name, suffixes) {
+			switch {
+			case stem == name:
+				return tbl, pk, true
+			case plural == nil && singular(stem) == singular(name):
+				plural, pluralPK = tbl, pk
+			}
+		}
+	}
+	return plural, pluralPK, plural != nil
+}
+
+// referenceStems lower case table names col may refer to, col without one of
+// the suffixes or without _<pk>
+func referenceStems(col, pk string, suffixes []string) []string {
+	var stems []string
+	for _, sfx := range append(append([]string{}, suffixes...), "_"+pk) {
+		if len(col) > len(sfx) && strings.HasSuffix(col, sfx) {
+			stems = append(stems, strings.ToLower(strings.TrimSuffix(col[:len(col)-len(sfx)], "_")))
+		}
+	}
+	return stems
+}
+
+func singlePK(tbl *Table) *Column {
+	var pk *Column
+	for _, c := range tbl.Columns {
+		if c.IsPrimaryKey {
+			if pk != nil {
+				return nil
+			}
+			pk = c
+		}
+	}
+	return pk
+}
+
+func stripPrefix(name string, prefixes []string) string {
+	for _, p := range prefixes {
+		if len(name) > len(p) && strings.HasPrefix(strings.ToLower(name), strings.ToLower(p)) {
+			return name[len(p):]
+		}
+	}
+	return name
+}
+
+// singular naive English singular, only used to compare two names
+func singular(s string) string {
+	switch {
+	case strings.HasSuffix(s, "ies") && len(s) > 3:
+		return s[:len(s)-3] + "y"
+	case strings.HasSuffix(s, "sses"), strings.HasSuffix(s, "xes"), strings.HasSuffix(s, "ches"), strings.HasSuffix(s, "shes"):
+		return s[:len(s)-2]
+	case strings.HasSuffix(s, "s") && !strings.HasSuffix(s, "ss"):
+		return s[:len(s)-1]
+	}
+	return s
+}
+
+// typeFamily integer, text or the lower case type without size and modifiers
+func typeFamily(t string) string {
+	t = strings.ToLower(strings.TrimSpace(t))
+	if i := strings.IndexAny(t, "( "); i > 0 {
+		t = t[:i]
+	}
+	switch t {
+	case "tinyint", "smallint", "mediumint", "int", "integer", "bigint",
+		"int2", "int4", "int8", "serial", "smallserial", "bigserial":
+		return "integer"
+	case "char", "varchar", "character", "text", "nchar", "nvarchar", "tinytext", "mediumtext", "longtext":
+		return "text"
+	}
+	return t
+}
+
+// compatibleTypes columns of the same type family, unknown types match anything
+func compatibleTypes(a, b string) bool {
+	if a == "" || b == "" {
+		return true
+	}
+	return typeFamily(a) == typeFamily(b)
+}
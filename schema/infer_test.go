@@ -0,0 +1,138 @@
+package schema
+
+import (
+	"testing"
+)
+
+func inferTables() []*Table {
+	table := func(name string, cols ...*Column) *Table {
+		return &Table{Name: name, Columns: cols}
+	}
+	pk := func(name, typ string) *Column {
+		return &Column{Name: name, DataType: typ, IsPrimaryKey: true, NotNull: true}
+	}
+	col := func(name, typ string) *Column {
+		return &Column{Name: name, DataType: typ}
+	}
+	return []*Table{
+		table("users", pk("id", "bigint")),
+		table("tbl_category", pk("id", "int")),
+		table("country", pk("code", "char(2)")),
+		table("orders", pk("id", "int"),
+			col("user_id", "int"),
+			col("categoryId", "integer"),
+			col("country_code", "varchar(2)"),
+			col("coupon_id", "int"),
+			col("users_fk", "uuid")),
+	}
+}
+
+func inferredRefs(tbls []*Table) map[string]string {
+	refs := map[string]string{}
+	for _, tbl := range tbls {
+		for _, fk := range tbl.ForeingKeys {
+			if !fk.Inferred || !fk.SourceColumn.IsForeignKey {
+				continue
+			}
+			refs[fk.SourceTableName+"."+fk.SourceColName] = fk.TargetTableName + "." + fk.TargetColName
+		}
+	}
+	return refs
+}
+
+func Test_InferForeignKeys(t *testing.T) {
+	tbls := inferTables()
+	InferForeignKeys(tbls, InferOptions{Prefixes: []string{"tbl_"}})
+	refs := inferredRefs(tbls)
+	for src, want := range map[string]string{
+		"orders.user_id":      "users.id",
+		"orders.categoryId":   "tbl_category.id",
+		"orders.country_code": "country.code",
+	} {
+		if refs[src] != want {
+			t.Errorf("%s references %q, want %s", src, refs[src], want)
+		}
+	}
+	// no coupon table, users_fk has an incompatible type
+	if len(refs) != 3 {
+		t.Errorf("unexpected references %v", refs)
+	}
+
+	tbls = inferTables()
+	InferForeignKeys(tbls, InferOptions{Suffixes: []string{"_fk"}})
+	// <table>_<pk> is always matched, categoryId needs the Id suffix
+	if refs := inferredRefs(tbls); len(refs) != 2 || refs["orders.categoryId"] != "" {
+		t.Errorf("only _<pk> references expected with the _fk suffix: %v", refs)
+	}
+}
+
+func Test_InferForeignKeys_mode(t *testing.T) {
+	real := func(tbls []*Table) {
+		users, orders := tbls[0], tbls[3]
+		orders.ForeingKeys = []*ForeignKey{{
+			ConstraintName: "orders_user_fkey", SourceTableName: "orders", SourceTable: orders,
+			SourceColName: "user_id", SourceColumn: orders.Columns[1],
+			TargetTableName: "users", TargetTable: users, TargetColName: "id", TargetColumn: users.Columns[0],
+		}}
+	}
+
+	tbls := inferTables()
+	real(tbls)
+	InferForeignKeys(tbls, InferOptions{})
+	if refs := inferredRefs(tbls); len(refs) != 0 {
+		t.Errorf("auto mode inferred next to real foreign keys: %v", refs)
+	}
+
+	tbls = inferTables()
+	real(tbls)
+	tbls = append(tbls, &Table{Name: "invoice", Columns: []*Column{
+		{Name: "id", DataType: "int", IsPrimaryKey: true},
+		{Name: "user_id", DataType: "bigint"},
+	}})
+	InferForeignKeys(tbls, InferOptions{Mode: InferMissing})
+	if refs := inferredRefs(tbls); len(refs) != 1 || refs["invoice.user_id"] != "users.id" {
+		t.Errorf("missing mode: %v", refs)
+	}
+
+	tbls = inferTables()
+	InferForeignKeys(tbls, InferOptions{Mode: InferOff})
+	if refs := inferredRefs(tbls); len(refs) != 0 {
+		t.Errorf("off mode: %v", refs)
+	}
+}
+
+func Test_DropUnconfirmed(t *testing.T) {
+	tbls := inferTables()
+	InferForeignKeys(tbls, InferOptions{Prefixes: []string{"tbl_"}})
+	for _, fk := range tbls[3].ForeingKeys {
+		switch fk.SourceColName {
+		case "user_id":
+			fk.Sampled, fk.Confidence = 100, 1
+		case "categoryId":
+			fk.Sampled, fk.Confidence = 100, 0.4
+		}
+	}
+	DropUnconfirmed(tbls, 0.9)
+	refs := inferredRefs(tbls)
+	// country_code was not sampled and is kept
+	if len(refs) != 2 || refs["orders.categoryId"] != "" || refs["orders.country_code"] == "" {
+		t.Errorf("unexpected references %v", refs)
+	}
+	if tbls[3].Columns[2].IsForeignKey {
+		t.Error("categoryId still marked as foreign key")
+	}
+}
+
+func Test_singular(t *testing.T) {
+	for in, want := range map[string]string{
+		"users":      "user",
+		"categories": "category",
+		"addresses":  "address",
+		"boxes":      "box",
+		"class":      "class",
+	} {
+		if got := singular(in); got != want {
+			t.Errorf("singular(%s) = %s, want %s", in, got, want)
+		}
+	}
+}
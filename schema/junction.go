@@ -0,0 +1,116 @@
+package schema
+
+// junctionMaxExtraColumns columns outside of the primary key a junction
+// table may have, e.g. created_at or granted_by
+const junctionMaxExtraColumns = 2
+
+// Junction many to many junction table, its primary key is made of the
+// columns of exactly two foreign keys
+type Junction struct {
+	Table *Table
+	Left  *ForeignKey
+	Right *ForeignKey
+}
+
+// FindJunctions junction tables of tbls whose referenced tables are in tbls
+// and are not junction tables themselves
+func FindJunctions(tbls []*Table) []*Junction {
+	// MySQL lists foreign keys on the referenced table, collect them by source
+	bySource := map[string][]*ForeignKey{}
+	for _, tbl := range tbls {
+		for _, fk := range tbl.ForeingKeys {
+			bySource[fk.SourceTableName] = append(bySource[fk.SourceTableName], fk)
+		}
+	}
+	var candidates []*Junction
+	for _, tbl := range tbls {
+		fks := bySource[tbl.Name]
+		if !tbl.IsCompositePK() || len(fks) != 2 {
+			continue
+		}
+		pk := map[*Column]bool{}
+		extra := 0
+		for _, c := range tbl.Columns {
+			if c.IsPrimaryKey {
+				pk[c] = true
+			} else {
+				extra++
+			}
+		}
+		if extra > junctionMaxExtraColumns {
+			continue
+		}
+		covered := map[*Column]bool{}
+		for _, fk := range fks {
+			for _, p := range fk.ColumnPairs() {
+				covered[p.SourceColumn] = true
+			}
+		}
+		if len(covered) != len(pk) || !containsAll(pk, covered) {
+			continue
+		}
+		if _, found := FindTableByName(tbls, fks[0].TargetTableName); !found {
+			continue
+		}
+		if _, found := FindTableByName(tbls, fks[1].TargetTableName); !found {
+			continue
+		}
+		candidates = append(candidates, &Junction{Table: tbl, Left: fks[0], Right: fks[1]})
+	}
+	// a junction referencing another one, e.g. abc(a_id, b_id, c_id) -> ab(a_id, b_id),
+	// would lose its end when that one is collapsed
+	isJunction := map[string]bool{}
+	for _, j := range candidates {
+		isJunction[j.Table.Name] = true
+	}
+	var junctions []*Junction
+	for _, j := range candidates {
+		if !isJunction[j.Left.TargetTableName] && !isJunction[j.Right.TargetTableName] {
+			junctions = append(junctions, j)
+		}
+	}
+	return junctions
+}
+
+func containsAll(set, cols map[*Column]bool) bool {
+	for c := range cols {
+		if !set[c] {
+			return false
+		}
+	}
+	return true
+}
+
+// CollapseJunctions copies of tbls without the junction tables, each one is
+// replaced by a many to many foreign key between the two tables it references,
+// named after the junction and listed on the left table, tbls is not modified
+func CollapseJunctions(tbls []*Table) []*Table {
+	junctions := FindJunctions(tbls)
+	if len(junctions) == 0 {
+		return tbls
+	}
+	isJunction := map[string]bool{}
+	for _, j := range junctions {
+		isJunction[j.Table.Name] = true
+	}
+	target := retain(tbls, func(name string) bool { return !isJunction[name] })
+	for _, j := range junctions {
+		left, _ := FindTableByName(target, j.Left.TargetTableName)
+		right, _ := FindTableByName(target, j.Right.TargetTableName)
+		left.ForeingKeys = append(left.ForeingKeys, &ForeignKey{
+			ConstraintName:        j.Table.Name,
+			SourceTableName:       left.Name,
+			SourceColName:         j.Left.TargetColName,
+			IsSourceColPrimaryKey: j.Left.TargetColumn.IsPrimaryKey,
+			SourceTable:           left,
+			SourceColumn:          j.Left.TargetColumn,
+			TargetTableName:       right.Name,
+			TargetColName:         j.Right.TargetColName,
+			IsTargetColPrimaryKey: j.Right.TargetColumn.IsPrimaryKey,
+			TargetTable:           right,
+			TargetColumn:          j.Right.TargetColumn,
+			Junction:              j.Table,
+		})
+	}
+	return target
+}
@@ -0,0 +1,102 @@
+package schema
+
+import (
+	"reflect"
+	"testing"
+)
+
+func junctionTables(extra ...string) []*Table {
+	users := &Table{Name: "users", Columns: []*Column{{Name: "id", IsPrimaryKey: true}}}
+	roles := &Table{Name: "roles", Columns: []*Column{{Name: "id", IsPrimaryKey: true}}}
+	ur := &Table{Name: "user_roles", Columns: []*Column{
+		{Name: "user_id", IsPrimaryKey: true, IsForeignKey: true},
+		{Name: "role_id", IsPrimaryKey: true, IsForeignKey: true},
+	}}
+	for _, name := range extra {
+		ur.Columns = append(ur.Columns, &Column{Name: name})
+	}
+	for i, target := range []*Table{users, roles} {
+		ur.ForeingKeys = append(ur.ForeingKeys, &ForeignKey{
+			ConstraintName:  ur.Columns[i].Name + "_fkey",
+			SourceTableName: ur.Name, SourceTable: ur,
+			SourceColName: ur.Columns[i].Name, SourceColumn: ur.Columns[i],
+			TargetTableName: target.Name, TargetTable: target,
+			TargetColName: "id", TargetColumn: target.Columns[0],
+		})
+	}
+	return []*Table{users, roles, ur}
+}
+
+func Test_FindJunctions(t *testing.T) {
+	js := FindJunctions(junctionTables("granted_at"))
+	if len(js) != 1 || js[0].Table.Name != "user_roles" || js[0].Left.TargetTableName != "users" || js[0].Right.TargetTableName != "roles" {
+		t.Fatalf("junctions = %+v", js)
+	}
+	if js := FindJunctions(junctionTables("a", "b", "c")); len(js) != 0 {
+		t.Errorf("table with 3 extra columns is a junction: %+v", js)
+	}
+	tbls := junctionTables()
+	tbls[2].Columns = append(tbls[2].Columns, &Column{Name: "scope", IsPrimaryKey: true})
+	if js := FindJunctions(tbls); len(js) != 0 {
+		t.Errorf("primary key column outside of the foreign keys: %+v", js)
+	}
+	if js := FindJunctions(junctionTables()[1:]); len(js) != 0 {
+		t.Errorf("junction to a missing table: %+v", js)
+	}
+}
+
+func Test_CollapseJunctions(t *testing.T) {
+	tbls := junctionTables()
+	got := CollapseJunctions(tbls)
+	if want := []string{"users", "roles"}; !reflect.DeepEqual(tableNames(got), want) {
+		t.Fatalf("got %v, want %v", tableNames(got), want)
+	}
+	fks := got[0].ForeingKeys
+	if len(fks) != 1 || fks[0].Junction != tbls[2] || fks[0].TargetTable != got[1] ||
+		fks[0].SourceCardinality() != ZeroOrMany || fks[0].TargetCardinality() != ZeroOrMany {
+		t.Errorf("many to many foreign key = %+v", fks)
+	}
+	if len(tbls[0].ForeingKeys) != 0 || len(tbls) != 3 {
+		t.Errorf("original tables were modified")
+	}
+}
+
+func Test_CollapseJunctions_nested(t *testing.T) {
+	tbls := junctionTables()
+	users, roles, ur := tbls[0], tbls[1], tbls[2]
+	scopes := &Table{Name: "scopes", Columns: []*Column{{Name: "id", IsPrimaryKey: true}}}
+	// user_role_scopes(user_id, role_id, scope_id) -> user_roles(user_id, role_id), scopes(id)
+	urs := &Table{Name: "user_role_scopes", Columns: []*Column{
+		{Name: "user_id", IsPrimaryKey: true, IsForeignKey: true},
+		{Name: "role_id", IsPrimaryKey: true, IsForeignKey: true},
+		{Name: "scope_id", IsPrimaryKey: true, IsForeignKey: true},
+	}}
+	urs.ForeingKeys = []*ForeignKey{
+		{
+			ConstraintName: "urs_user_role_fkey", SourceTableName: urs.Name, SourceTable: urs,
+			TargetTableName: ur.Name, TargetTable: ur,
+			Columns: []*ForeignKeyColumn{
+				{SourceColName: "user_id", SourceColumn: urs.Columns[0], TargetColName: "user_id", TargetColumn: ur.Columns[0]},
+				{SourceColName: "role_id", SourceColumn: urs.Columns[1], TargetColName: "role_id", TargetColumn: ur.Columns[1]},
+			},
+		},
+		{
+			ConstraintName: "urs_scope_fkey", SourceTableName: urs.Name, SourceTable: urs,
+			SourceColName: "scope_id", SourceColumn: urs.Columns[2],
+			TargetTableName: scopes.Name, TargetTable: scopes, TargetColName: "id", TargetColumn: scopes.Columns[0],
+		},
+	}
+	first := urs.ForeingKeys[0]
+	first.SourceColName, first.SourceColumn = "user_id", urs.Columns[0]
+	first.TargetColName, first.TargetColumn = "user_id", ur.Columns[0]
+	tbls = []*Table{users, roles, ur, scopes, urs}
+
+	js := FindJunctions(tbls)
+	if len(js) != 1 || js[0].Table != ur {
+		t.Fatalf("junctions = %+v", js)
+	}
+	got := CollapseJunctions(tbls)
+	if want := []string{"users", "roles", "scopes", "user_role_scopes"}; !reflect.DeepEqual(tableNames(got), want) {
+		t.Errorf("got %v, want %v", tableNames(got), want)
+	}
+}
@@ -0,0 +1,370 @@
+// Package schema is the table model shared by the drivers and the renderers
+package schema
+
+import (
+	"database/sql"
+	"path"
+	"regexp"
+
+	"github.com/pkg/errors"
+)
+
+// Schema tables loaded from a database or a schema file
+type Schema struct {
+	Tables []*Table
+}
+
+// Column postgres columns
+type Column struct {
+	FieldOrdinal int
+	Name         string
+	Comment      sql.NullString
+	DataType     string
+	DDLType      string
+	NotNull      bool
+	// Default default value expression, invalid when the column has none
+	Default sql.NullString
+	// Extra MySQL style extras, e.g. auto_increment, on update CURRENT_TIMESTAMP,
+	// VIRTUAL GENERATED
+	Extra        string
+	IsPrimaryKey bool
+	IsForeignKey bool
+	// IsUnique column alone is a unique key, columns of composite unique keys
+	// are only listed in Table.UniqueKeys
+	IsUnique bool
+}
+
+// ForeignKey foreign key
+type ForeignKey struct {
+	ConstraintName        string
+	SourceTableName       string
+	SourceColName         string
+	IsSourceColPrimaryKey bool
+	SourceTable           *Table
+	SourceColumn          *Column
+	TargetTableName       string
+	TargetColName         string
+	IsTargetColPrimaryKey bool
+	TargetTable           *Table
+	TargetColumn          *Column
+	// Columns column pairs in key order, the column fields above hold the
+	// first pair
+	Columns []*ForeignKeyColumn
+	// Junction junction table of a many to many relation made by
+	// CollapseJunctions, the columns are the referenced columns of both ends
+	Junction *Table
+	// Inferred guessed from column names by InferForeignKeys, not a constraint
+	Inferred bool
+	// Sampled source rows looked up in the target by value sampling, 0 when
+	// the foreign key was not sampled
+	Sampled int
+	// Confidence share of the Sampled rows whose value exists in the target
+	Confidence float64
+}
+
+// ForeignKeyColumn source and target column of a foreign key
+type ForeignKeyColumn struct {
+	SourceColName string
+	SourceColumn  *Column
+	TargetColName string
+	TargetColumn  *Column
+}
+
+// ColumnPairs column pairs in key order, the first pair when Columns is not set
+func (k *ForeignKey) ColumnPairs() []*ForeignKeyColumn {
+	if len(k.Columns) != 0 {
+		return k.Columns
+	}
+	return []*ForeignKeyColumn{{
+		SourceColName: k.SourceColName,
+		SourceColumn:  k.SourceColumn,
+		TargetColName: k.TargetColName,
+		TargetColumn:  k.TargetColumn,
+	}}
+}
+
+// SourceColNames source column names in key order
+func (k *ForeignKey) SourceColNames() []string {
+	var names []string
+	for _, p := range k.ColumnPairs() {
+		names = append(names, p.SourceColName)
+	}
+	return names
+}
+
+// TargetColNames target column names in key order
+func (k *ForeignKey) TargetColNames() []string {
+	var names []string
+	for _, p := range k.ColumnPairs() {
+		names = append(names, p.TargetColName)
+	}
+	return names
+}
+
+// GroupForeignKeys merge the foreign keys loaded one per column pair into one
+// foreign key per constraint of a source table, pairs keep their order
+func GroupForeignKeys(fks []*ForeignKey) []*ForeignKey {
+	var grouped []*ForeignKey
+	byName := map[string]*ForeignKey{}
+	for _, fk := range fks {
+		key := fk.SourceTableName + "." + fk.ConstraintName
+		if g, found := byName[key]; found && fk.ConstraintName != "" {
+			g.Columns = append(g.Columns, fk.ColumnPairs()...)
+			continue
+		}
+		fk.Columns = append([]*ForeignKeyColumn(nil), fk.ColumnPairs()...)
+		byName[key] = fk
+		grouped = append(grouped, fk)
+	}
+	return grouped
+}
+
+// IsOneToOne returns true if a target row is referenced by at most one source
+// row, i.e. the source columns hold the whole primary key or a whole unique
+// key of the source table
+func (k *ForeignKey) IsOneToOne() bool {
+	source := map[*Column]bool{}
+	for _, p := range k.ColumnPairs() {
+		source[p.SourceColumn] = true
+		if p.SourceColumn != nil && p.SourceColumn.IsUnique {
+			return true
+		}
+	}
+	covers := func(cols []*Column) bool {
+		for _, c := range cols {
+			if !source[c] {
+				return false
+			}
+		}
+		return len(cols) != 0
+	}
+	if k.SourceTable == nil {
+		return false
+	}
+	var pk []*Column
+	for _, c := range k.SourceTable.Columns {
+		if c.IsPrimaryKey {
+			pk = append(pk, c)
+		}
+	}
+	if covers(pk) {
+		return true
+	}
+	for _, uk := range k.SourceTable.UniqueKeys {
+		if covers(uk.Columns) {
+			return true
+		}
+	}
+	return false
+}
+
+// Cardinality number of rows at one end of a relation for a row at the other end
+type Cardinality int
+
+// Cardinalities of the crow's foot notation
+const (
+	ZeroOrOne Cardinality = iota
+	ExactlyOne
+	ZeroOrMany
+	OneOrMany
+)
+
+// SourceCardinality source rows referencing a target row, a schema cannot
+// require children so it is ZeroOrOne for one to one relations, else ZeroOrMany
+func (k *ForeignKey) SourceCardinality() Cardinality {
+	if k.Junction != nil {
+		return ZeroOrMany
+	}
+	if k.IsOneToOne() {
+		return ZeroOrOne
+	}
+	return ZeroOrMany
+}
+
+// TargetCardinality target rows referenced by a source row, ZeroOrOne when
+// a source column is nullable, unresolved source columns count as not null
+func (k *ForeignKey) TargetCardinality() Cardinality {
+	if k.Junction != nil {
+		return ZeroOrMany
+	}
+	for _, p := range k.ColumnPairs() {
+		if p.SourceColumn == nil {
+			continue
+		}
+		if !p.SourceColumn.NotNull && !p.SourceColumn.IsPrimaryKey {
+			return ZeroOrOne
+		}
+	}
+	return ExactlyOne
+}
+
+// Table postgres table
+type Table struct {
+	Name        string
+	Comment     sql.NullString
+	AutoGenPk   bool
+	Columns     []*Column
+	ForeingKeys []*ForeignKey
+	UniqueKeys  []*UniqueKey
+	Indexes     []*Index
+}
+
+// Index secondary index, the primary key index is not listed
+type Index struct {
+	Name string
+	// ColumnNames key columns, the expression of expression keys and the
+	// prefix length of MySQL prefix keys, e.g. name(10)
+	ColumnNames []string
+	Unique      bool
+	// Method access method in lower case, e.g. btree, gin, fulltext
+	Method string
+	// Predicate WHERE clause of a partial index
+	Predicate string
+}
+
+// UniqueKey named unique constraint, primary keys are not listed
+type UniqueKey struct {
+	ConstraintName string
+	ColumnNames    []string
+	Columns        []*Column
+}
+
+// IsCompositePK check if table is composite pk
+func (t *Table) IsCompositePK() bool {
+	cnt := 0
+	for _, c := range t.Columns {
+		if c.IsPrimaryKey {
+			cnt++
+		}
+		if cnt >= 2 {
+			return true
+		}
+	}
+	return false
+}
+
+// FindTableByName find table by name
+func FindTableByName(tbls []*Table, name string) (*Table, bool) {
+	for _, tbl := range tbls {
+		if tbl.Name == name {
+			return tbl, true
+		}
+	}
+	return nil, false
+}
+
+// FindColumnByName find table by name
+func FindColumnByName(tbls []*Table, tableName, colName string) (*Column, bool) {
+	for _, tbl := range tbls {
+		if tbl.Name == tableName {
+			for _, col := range tbl.Columns {
+				if col.Name == colName {
+					return col, true
+				}
+			}
+		}
+	}
+	return nil, false
+}
+
+// MatchMode how -t/-x patterns are matched against table names
+type MatchMode string
+
+// Match modes of TableFilter
+const (
+	// MatchExact the pattern is the table name
+	MatchExact MatchMode = "exact"
+	// MatchGlob shell pattern, * ? and [a-z] match the whole name
+	MatchGlob MatchMode = "glob"
+	// MatchRegex regular expression matching the whole name
+	MatchRegex MatchMode = "regex"
+)
+
+// tableMatcher report whether a name matches any of patterns, nil patterns match nothing
+func tableMatcher(patterns []string, mode MatchMode) (func(name string) bool, error) {
+	var matchers []func(name string) bool
+	for _, p := range patterns {
+		p := p
+		switch mode {
+		case MatchExact:
+			matchers = append(matchers, func(name string) bool { return name == p })
+		case MatchGlob, "":
+			if _, err := path.Match(p, ""); err != nil {
+				return nil, errors.Wrapf(err, "invalid table pattern %s", p)
+			}
+			matchers = append(matchers, func(name string) bool {
+				ok, _ := path.Match(p, name)
+				return ok
+			})
+		case MatchRegex:
+			r, err := regexp.Compile(`^(?:` + p + `)$`)
+			if err != nil {
+				return nil, errors.Wrapf(err, "invalid table pattern %s", p)
+			}
+			matchers = append(matchers, r.MatchString)
+		default:
+			return nil, errors.Errorf("unknown match mode %s, use exact/glob/regex", mode)
+		}
+	}
+	return func(name string) bool {
+		for _, m := range matchers {
+			if m(name) {
+				return true
+			}
+		}
+		return false
+	}, nil
+}
+
+// TableFilter report whether a table is kept with tblNames as the targets and
+// xTblNames as the exclusions, no targets keep every table not excluded
+func TableFilter(tblNames, xTblNames []string, mode MatchMode) (func(name string) bool, error) {
+	match, err := tableMatcher(tblNames, mode)
+	if err != nil {
+		return nil, err
+	}
+	exclude, err := tableMatcher(xTblNames, mode)
+	if err != nil {
+		return nil, err
+	}
+	return func(name string) bool {
+		if len(tblNames) != 0 && !match(name) {
+			return false
+		}
+		return !exclude(name)
+	}, nil
+}
+
+// FilterTables tables kept by keep, tbls is not modified
+func FilterTables(tbls []*Table, keep func(name string) bool) []*Table {
+	return retain(tbls, keep)
+}
+
+// retain copy the tables kept by keep with the foreign keys between them,
+// the copied foreign keys link to the copied tables, columns are shared
+func retain(tbls []*Table, keep func(name string) bool) []*Table {
+	copies := map[string]*Table{}
+	var target []*Table
+	for _, tbl := range tbls {
+		if keep(tbl.Name) {
+			cp := *tbl
+			copies[tbl.Name] = &cp
+			target = append(target, &cp)
+		}
+	}
+	for _, tbl := range target {
+		var fks []*ForeignKey
+		for _, fk := range tbl.ForeingKeys {
+			source, sourceKept := copies[fk.SourceTableName]
+			targetTbl, targetKept := copies[fk.TargetTableName]
+			if !sourceKept || !targetKept {
+				continue
+			}
+			cp := *fk
+			cp.SourceTable, cp.TargetTable = source, targetTbl
+			fks = append(fks, &cp)
+		}
+		tbl.ForeingKeys = fks
+	}
+	return target
+}
@@ -0,0 +1,137 @@
+package schema
+
+import (
+	"reflect"
+	"testing"
+)
+
+func Test_TableFilter(t *testing.T) {
+	names := []string{"user", "user_audit", "superuser", "users_tmp", "order"}
+	for _, tc := range []struct {
+		tbls, xtbls []string
+		mode        MatchMode
+		want        []string
+	}{
+		{[]string{"user"}, nil, MatchExact, []string{"user"}},
+		{[]string{"user"}, nil, MatchGlob, []string{"user"}},
+		{[]string{"user*"}, []string{"*_tmp"}, MatchGlob, []string{"user", "user_audit"}},
+		{[]string{"user(_audit)?"}, nil, MatchRegex, []string{"user", "user_audit"}},
+		{nil, []string{"user"}, MatchRegex, []string{"user_audit", "superuser", "users_tmp", "order"}},
+	} {
+		keep, err := TableFilter(tc.tbls, tc.xtbls, tc.mode)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var got []string
+		for _, n := range names {
+			if keep(n) {
+				got = append(got, n)
+			}
+		}
+		if !reflect.DeepEqual(got, tc.want) {
+			t.Errorf("%s %v -x %v: got %v, want %v", tc.mode, tc.tbls, tc.xtbls, got, tc.want)
+		}
+	}
+
+	for _, tc := range []struct {
+		pattern string
+		mode    MatchMode
+	}{
+		{"user[", MatchGlob},
+		{"user(", MatchRegex},
+		{"user", "fuzzy"},
+	} {
+		if _, err := TableFilter([]string{tc.pattern}, nil, tc.mode); err == nil {
+			t.Errorf("%s %s: expected error", tc.mode, tc.pattern)
+		}
+	}
+}
+
+func Test_FilterTables(t *testing.T) {
+	tbls := chainTables()
+	got := FilterTables(tbls, func(name string) bool { return name != "c" })
+	if want := []string{"a", "b", "d", "e", "f"}; !reflect.DeepEqual(tableNames(got), want) {
+		t.Errorf("got %v, want %v", tableNames(got), want)
+	}
+	b, _ := FindTableByName(got, "b")
+	if len(b.ForeingKeys) != 0 {
+		t.Errorf("b keeps the foreign key to dropped table c")
+	}
+	a, _ := FindTableByName(got, "a")
+	if a.ForeingKeys[0].TargetTable != b {
+		t.Errorf("a_b is not linked to the filtered b")
+	}
+	if len(tbls[1].ForeingKeys) != 1 || tbls[1].ForeingKeys[0].TargetTable != tbls[2] {
+		t.Errorf("original tables were modified")
+	}
+}
+
+func Test_HideColumns(t *testing.T) {
+	cols := func(names ...string) []*Column {
+		var cs []*Column
+		for i, n := range names {
+			cs = append(cs, &Column{FieldOrdinal: i + 1, Name: n, IsPrimaryKey: n == "id", IsForeignKey: n == "user_id"})
+		}
+		return cs
+	}
+	tbls := []*Table{
+		{Name: "orders", Columns: cols("id", "user_id", "payload", "created_at")},
+		{Name: "audit_log", Columns: cols("id", "payload", "created_at")},
+	}
+	columnNames := func(tbl *Table) []string {
+		var names []string
+		for _, c := range tbl.Columns {
+			names = append(names, c.Name)
+		}
+		return names
+	}
+
+	hide, err := ColumnFilter([]string{"created_at", "audit_*.payload"}, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := HideColumns(tbls, hide)
+	if want := []string{"id", "user_id", "payload"}; !reflect.DeepEqual(columnNames(got[0]), want) {
+		t.Errorf("orders: got %v, want %v", columnNames(got[0]), want)
+	}
+	if want := []string{"id"}; !reflect.DeepEqual(columnNames(got[1]), want) {
+		t.Errorf("audit_log: got %v, want %v", columnNames(got[1]), want)
+	}
+	if len(tbls[0].Columns) != 4 {
+		t.Errorf("original columns were modified")
+	}
+
+	hide, err = ColumnFilter(nil, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"id", "user_id"}; !reflect.DeepEqual(columnNames(HideColumns(tbls, hide)[0]), want) {
+		t.Errorf("keys only: got %v, want %v", columnNames(HideColumns(tbls, hide)[0]), want)
+	}
+
+	for _, p := range []string{"[", "orders.", ".id"} {
+		if _, err := ColumnFilter([]string{p}, false); err == nil {
+			t.Errorf("%s: expected error", p)
+		}
+	}
+}
+
+func Test_Cardinality_unresolvedColumns(t *testing.T) {
+	// foreign keys built by name only, e.g. by a library user
+	fk := &ForeignKey{SourceTableName: "login", SourceColName: "account_id", TargetTableName: "account", TargetColName: "id"}
+	if got := fk.TargetCardinality(); got != ExactlyOne {
+		t.Errorf("TargetCardinality = %v, want ExactlyOne", got)
+	}
+	if got := fk.SourceCardinality(); got != ZeroOrMany {
+		t.Errorf("SourceCardinality = %v, want ZeroOrMany", got)
+	}
+
+	nullable := &Column{Name: "invite_id"}
+	fk.Columns = []*ForeignKeyColumn{
+		{SourceColName: "account_id", TargetColName: "id"},
+		{SourceColName: "invite_id", SourceColumn: nullable, TargetColName: "invite_id"},
+	}
+	if got := fk.TargetCardinality(); got != ZeroOrOne {
+		t.Errorf("TargetCardinality with a nullable column = %v, want ZeroOrOne", got)
+	}
+}
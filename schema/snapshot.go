@@ -0,0 +1,268 @@
+package schema
+
+import (
+	"database/sql"
+
+	"github.com/pkg/errors"
+)
+
+// SnapshotVersion version of the snapshot format written by planter
+const SnapshotVersion = 1
+
+// Snapshot serializable schema, foreign keys refer to tables and columns by name
+type Snapshot struct {
+	Version int              `json:"version"`
+	Tables  []*SnapshotTable `json:"tables"`
+}
+
+// SnapshotTable table of a snapshot
+type SnapshotTable struct {
+	Name        string                `json:"name"`
+	Comment     *string               `json:"comment,omitempty"`
+	AutoGenPk   bool                  `json:"auto_gen_pk,omitempty"`
+	Columns     []*SnapshotColumn     `json:"columns"`
+	UniqueKeys  []*SnapshotUniqueKey  `json:"unique_keys,omitempty"`
+	Indexes     []*SnapshotIndex      `json:"indexes,omitempty"`
+	ForeignKeys []*SnapshotForeignKey `json:"foreign_keys,omitempty"`
+}
+
+// SnapshotColumn column of a snapshot
+type SnapshotColumn struct {
+	Ordinal      int     `json:"ordinal"`
+	Name         string  `json:"name"`
+	DataType     string  `json:"data_type"`
+	DDLType      string  `json:"ddl_type,omitempty"`
+	NotNull      bool    `json:"not_null"`
+	Default      *string `json:"default,omitempty"`
+	Extra        string  `json:"extra,omitempty"`
+	IsPrimaryKey bool    `json:"primary_key,omitempty"`
+	IsForeignKey bool    `json:"foreign_key,omitempty"`
+	IsUnique     bool    `json:"unique,omitempty"`
+	Comment      *string `json:"comment,omitempty"`
+}
+
+// SnapshotUniqueKey unique key of a snapshot
+type SnapshotUniqueKey struct {
+	Name    string   `json:"name"`
+	Columns []string `json:"columns"`
+}
+
+// SnapshotIndex index of a snapshot
+type SnapshotIndex struct {
+	Name      string   `json:"name"`
+	Columns   []string `json:"columns"`
+	Unique    bool     `json:"unique,omitempty"`
+	Method    string   `json:"method,omitempty"`
+	Predicate string   `json:"predicate,omitempty"`
+}
+
+// SnapshotForeignKey foreign key of a snapshot, listed on the same table as in
+// the loaded schema (the referenced table for MySQL), an empty SourceTable is
+// the owning table. Composite keys list every column in SourceColumns/TargetColumns
+type SnapshotForeignKey struct {
+	Name          string   `json:"name"`
+	SourceTable   string   `json:"source_table,omitempty"`
+	SourceColumn  string   `json:"source_column"`
+	TargetTable   string   `json:"target_table"`
+	TargetColumn  string   `json:"target_column"`
+	SourceColumns []string `json:"source_columns,omitempty"`
+	TargetColumns []string `json:"target_columns,omitempty"`
+	Inferred      bool     `json:"inferred,omitempty"`
+	Sampled       int      `json:"sampled,omitempty"`
+	Confidence    float64  `json:"confidence,omitempty"`
+}
+
+func nullStringPtr(s sql.NullString) *string {
+	if !s.Valid {
+		return nil
+	}
+	v := s.String
+	return &v
+}
+
+func ptrNullString(s *string) sql.NullString {
+	if s == nil {
+		return sql.NullString{}
+	}
+	return sql.NullString{String: *s, Valid: true}
+}
+
+// TablesToSnapshot convert loaded tables to a snapshot
+func TablesToSnapshot(tbls []*Table) *Snapshot {
+	s := &Snapshot{
+		Version: SnapshotVersion,
+		Tables:  []*SnapshotTable{},
+	}
+	for _, tbl := range tbls {
+		st := &SnapshotTable{
+			Name:      tbl.Name,
+			Comment:   nullStringPtr(tbl.Comment),
+			AutoGenPk: tbl.AutoGenPk,
+			Columns:   []*SnapshotColumn{},
+		}
+		for _, c := range tbl.Columns {
+			st.Columns = append(st.Columns, &SnapshotColumn{
+				Ordinal:      c.FieldOrdinal,
+				Name:         c.Name,
+				DataType:     c.DataType,
+				DDLType:      c.DDLType,
+				NotNull:      c.NotNull,
+				Default:      nullStringPtr(c.Default),
+				Extra:        c.Extra,
+				IsPrimaryKey: c.IsPrimaryKey,
+				IsForeignKey: c.IsForeignKey,
+				IsUnique:     c.IsUnique,
+				Comment:      nullStringPtr(c.Comment),
+			})
+		}
+		for _, uk := range tbl.UniqueKeys {
+			st.UniqueKeys = append(st.UniqueKeys, &SnapshotUniqueKey{
+				Name:    uk.ConstraintName,
+				Columns: uk.ColumnNames,
+			})
+		}
+		for _, ix := range tbl.Indexes {
+			st.Indexes = append(st.Indexes, &SnapshotIndex{
+				Name:      ix.Name,
+				Columns:   ix.ColumnNames,
+				Unique:    ix.Unique,
+				Method:    ix.Method,
+				Predicate: ix.Predicate,
+			})
+		}
+		for _, fk := range tbl.ForeingKeys {
+			sfk := &SnapshotForeignKey{
+				Name:         fk.ConstraintName,
+				SourceTable:  fk.SourceTableName,
+				SourceColumn: fk.SourceColName,
+				TargetTable:  fk.TargetTableName,
+				TargetColumn: fk.TargetColName,
+				Inferred:     fk.Inferred,
+				Sampled:      fk.Sampled,
+				Confidence:   fk.Confidence,
+			}
+			if len(fk.ColumnPairs()) > 1 {
+				sfk.SourceColumns, sfk.TargetColumns = fk.SourceColNames(), fk.TargetColNames()
+			}
+			st.ForeignKeys = append(st.ForeignKeys, sfk)
+		}
+		s.Tables = append(s.Tables, st)
+	}
+	return s
+}
+
+// ToTables rebuild tables and re-link foreign keys to their tables and columns
+func (s *Snapshot) ToTables() ([]*Table, error) {
+	if s.Version != SnapshotVersion {
+		return nil, errors.Errorf("unsupported snapshot version %d", s.Version)
+	}
+	var tbls []*Table
+	for _, st := range s.Tables {
+		tbl := &Table{
+			Name:      st.Name,
+			Comment:   ptrNullString(st.Comment),
+			AutoGenPk: st.AutoGenPk,
+		}
+		for _, sc := range st.Columns {
+			tbl.Columns = append(tbl.Columns, &Column{
+				FieldOrdinal: sc.Ordinal,
+				Name:         sc.Name,
+				Comment:      ptrNullString(sc.Comment),
+				DataType:     sc.DataType,
+				DDLType:      sc.DDLType,
+				NotNull:      sc.NotNull,
+				Default:      ptrNullString(sc.Default),
+				Extra:        sc.Extra,
+				IsPrimaryKey: sc.IsPrimaryKey,
+				IsForeignKey: sc.IsForeignKey,
+				IsUnique:     sc.IsUnique,
+			})
+		}
+		for _, suk := range st.UniqueKeys {
+			uk := &UniqueKey{ConstraintName: suk.Name, ColumnNames: suk.Columns}
+			for _, name := range suk.Columns {
+				col, found := FindColumnByName([]*Table{tbl}, tbl.Name, name)
+				if !found {
+					return nil, errors.Errorf("%s: %s.%s not found", suk.Name, tbl.Name, name)
+				}
+				uk.Columns = append(uk.Columns, col)
+			}
+			tbl.UniqueKeys = append(tbl.UniqueKeys, uk)
+		}
+		for _, si := range st.Indexes {
+			tbl.Indexes = append(tbl.Indexes, &Index{
+				Name:        si.Name,
+				ColumnNames: si.Columns,
+				Unique:      si.Unique,
+				Method:      si.Method,
+				Predicate:   si.Predicate,
+			})
+		}
+		tbls = append(tbls, tbl)
+	}
+	for i, st := range s.Tables {
+		tbl := tbls[i]
+		for _, sfk := range st.ForeignKeys {
+			fk, err := sfk.toForeignKey(tbls, tbl)
+			if err != nil {
+				return nil, err
+			}
+			tbl.ForeingKeys = append(tbl.ForeingKeys, fk)
+		}
+	}
+	return tbls, nil
+}
+
+// toForeignKey resolve the tables and columns of sfk listed on owner
+func (sfk *SnapshotForeignKey) toForeignKey(tbls []*Table, owner *Table) (*ForeignKey, error) {
+	tbl := owner
+	if sfk.SourceTable != "" {
+		var found bool
+		if tbl, found = FindTableByName(tbls, sfk.SourceTable); !found {
+			return nil, errors.Errorf("%s: %s not found", sfk.Name, sfk.SourceTable)
+		}
+	}
+	targetTbl, found := FindTableByName(tbls, sfk.TargetTable)
+	if !found {
+		return nil, errors.Errorf("%s: %s not found", sfk.Name, sfk.TargetTable)
+	}
+	sourceNames, targetNames := sfk.SourceColumns, sfk.TargetColumns
+	if len(sourceNames) == 0 && len(targetNames) == 0 {
+		sourceNames, targetNames = []string{sfk.SourceColumn}, []string{sfk.TargetColumn}
+	}
+	if len(sourceNames) != len(targetNames) {
+		return nil, errors.Errorf("%s: %d source columns but %d target columns", sfk.Name, len(sourceNames), len(targetNames))
+	}
+	fk := &ForeignKey{
+		ConstraintName:  sfk.Name,
+		SourceTableName: tbl.Name,
+		SourceTable:     tbl,
+		TargetTableName: targetTbl.Name,
+		TargetTable:     targetTbl,
+		Inferred:        sfk.Inferred,
+		Sampled:         sfk.Sampled,
+		Confidence:      sfk.Confidence,
+	}
+	for i := range sourceNames {
+		sourceCol, found := FindColumnByName(tbls, tbl.Name, sourceNames[i])
+		if !found {
+			return nil, errors.Errorf("%s: %s.%s not found", sfk.Name, tbl.Name, sourceNames[i])
+		}
+		targetCol, found := FindColumnByName(tbls, targetTbl.Name, targetNames[i])
+		if !found {
+			return nil, errors.Errorf("%s: %s.%s not found", sfk.Name, targetTbl.Name, targetNames[i])
+		}
+		fk.Columns = append(fk.Columns, &ForeignKeyColumn{
+			SourceColName: sourceCol.Name,
+			SourceColumn:  sourceCol,
+			TargetColName: targetCol.Name,
+			TargetColumn:  targetCol,
+		})
+	}
+	first := fk.Columns[0]
+	fk.SourceColName, fk.SourceColumn = first.SourceColName, first.SourceColumn
+	fk.TargetColName, fk.TargetColumn = first.TargetColName, first.TargetColumn
+	fk.IsSourceColPrimaryKey = first.SourceColumn.IsPrimaryKey
+	fk.IsTargetColPrimaryKey = first.TargetColumn.IsPrimaryKey
+	return fk, nil
+}
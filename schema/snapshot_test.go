@@ -0,0 +1,89 @@
+package schema
+
+import "testing"
+
+func Test_Snapshot_errors(t *testing.T) {
+	s := &Snapshot{Version: 2}
+	if _, err := s.ToTables(); err == nil {
+		t.Error("expected version error")
+	}
+	s = &Snapshot{
+		Version: SnapshotVersion,
+		Tables: []*SnapshotTable{{
+			Name:        "a",
+			Columns:     []*SnapshotColumn{{Ordinal: 1, Name: "b_id", DataType: "int"}},
+			ForeignKeys: []*SnapshotForeignKey{{Name: "fk", SourceColumn: "b_id", TargetTable: "b", TargetColumn: "id"}},
+		}},
+	}
+	if _, err := s.ToTables(); err == nil {
+		t.Error("expected error for unknown target table")
+	}
+}
+
+func Test_Snapshot_compositeFK(t *testing.T) {
+	line := &Table{Name: "line", Columns: []*Column{
+		{FieldOrdinal: 1, Name: "order_id", IsPrimaryKey: true, NotNull: true},
+		{FieldOrdinal: 2, Name: "no", IsPrimaryKey: true, NotNull: true},
+	}}
+	note := &Table{Name: "note", Columns: []*Column{
+		{FieldOrdinal: 1, Name: "id", IsPrimaryKey: true, NotNull: true},
+		{FieldOrdinal: 2, Name: "order_id", NotNull: true},
+		{FieldOrdinal: 3, Name: "line_no", NotNull: true},
+	}}
+	pair := func(src, dst int) *ForeignKey {
+		return &ForeignKey{
+			ConstraintName: "note_line_fk", SourceTableName: "note", SourceTable: note,
+			SourceColName: note.Columns[src].Name, SourceColumn: note.Columns[src],
+			TargetTableName: "line", TargetTable: line,
+			TargetColName: line.Columns[dst].Name, TargetColumn: line.Columns[dst],
+		}
+	}
+	note.ForeingKeys = GroupForeignKeys([]*ForeignKey{pair(1, 0), pair(2, 1)})
+	if len(note.ForeingKeys) != 1 || len(note.ForeingKeys[0].Columns) != 2 {
+		t.Fatalf("grouped foreign keys = %+v", note.ForeingKeys)
+	}
+
+	tbls, err := TablesToSnapshot([]*Table{line, note}).ToTables()
+	if err != nil {
+		t.Fatal(err)
+	}
+	fk := tbls[1].ForeingKeys[0]
+	if got := fk.TargetColNames(); len(got) != 2 || got[1] != "no" || fk.Columns[1].TargetColumn != tbls[0].Columns[1] {
+		t.Errorf("composite foreign key not restored: %+v", fk.Columns)
+	}
+	if d := DiffTables([]*Table{line, note}, tbls); !d.Empty() {
+		t.Errorf("snapshot changed the schema: %+v", d)
+	}
+}
+
+func Test_Snapshot_mysqlOwnedFK(t *testing.T) {
+	users := &Table{Name: "users", Columns: []*Column{
+		{FieldOrdinal: 1, Name: "id", IsPrimaryKey: true, NotNull: true},
+	}}
+	orders := &Table{Name: "orders", Columns: []*Column{
+		{FieldOrdinal: 1, Name: "id", IsPrimaryKey: true, NotNull: true},
+		{FieldOrdinal: 2, Name: "buyer_id", NotNull: true, IsForeignKey: true},
+	}}
+	// the mysql driver lists foreign keys on the referenced table
+	users.ForeingKeys = []*ForeignKey{{
+		ConstraintName: "orders_buyer_fk", SourceTableName: "orders", SourceTable: orders,
+		SourceColName: "buyer_id", SourceColumn: orders.Columns[1],
+		TargetTableName: "users", TargetTable: users, TargetColName: "id", TargetColumn: users.Columns[0],
+		IsTargetColPrimaryKey: true,
+	}}
+
+	tbls, err := TablesToSnapshot([]*Table{users, orders}).ToTables()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(tbls[0].ForeingKeys) != 1 || len(tbls[1].ForeingKeys) != 0 {
+		t.Fatalf("foreign key moved to another table: %+v %+v", tbls[0].ForeingKeys, tbls[1].ForeingKeys)
+	}
+	fk := tbls[0].ForeingKeys[0]
+	if fk.SourceTable != tbls[1] || fk.SourceColumn != tbls[1].Columns[1] || fk.TargetColumn != tbls[0].Columns[0] {
+		t.Errorf("foreign key not restored: %s.%s -> %s.%s", fk.SourceTableName, fk.SourceColName, fk.TargetTableName, fk.TargetColName)
+	}
+	if d := DiffTables([]*Table{users, orders}, tbls); !d.Empty() {
+		t.Errorf("snapshot changed the schema: %+v", d)
+	}
+}
@@ -1,7 +0,0 @@
-package main
-
-import "testing"
-
-func Test_genSVG(t *testing.T) {
-
-}
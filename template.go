@@ -1,25 +0,0 @@
-package main
-
-const entryTmpl = `
-entity "**{{ .Name }}**" {
-{{- if .Comment.Valid  }}
-  {{ .Comment.String }}
-  ..
-{{- end }}
-{{- range .Columns }}
-  {{- if .IsPrimaryKey }}
-  + ""{{ .Name }}"": //{{ .DataType }} [PK]{{if .IsForeignKey }}[FK]{{end}}{{- if .Comment.Valid }} : {{ .Comment.String }}{{- end }}//
-  {{- end }}
-{{- end }}
-  --
-{{- range .Columns }}
-  {{- if not .IsPrimaryKey }}
-  {{if .NotNull}}*{{end}}""{{ .Name }}"": //{{ .DataType }} {{if .IsForeignKey}}[FK]{{end}} {{- if .Comment.Valid }} : {{ .Comment.String }}{{- end }}//
-  {{- end }}
-{{- end }}
-}
-`
-
-const relationTmpl = `
-"**{{ .SourceTableName }}**" {{if .IsOneToOne}} ||---|| {{else}}  }---|| {{end}} "**{{ .TargetTableName }}**"
-`